@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/bufbuild/protovalidate-go"
+)
+
+const bootstrapUsage = `usage: radiusxctl bootstrap-admin [service flags] <email> <name>
+
+Creates the first admin straight in the database, for when there is no
+admin to do it through the API. The database is configured as for the
+service, from DB_* variables, CONFIG_FILE or the service flags, and must be
+migrated. It refuses to run once any admin exists.
+
+The password is taken from RADIUSXCTL_ADMIN_PASSWORD or the first line of
+stdin. A running service may serve cached user lists without the new admin
+until they expire.`
+
+var errAdminExists = errors.New("an admin already exists, manage users through the API")
+
+func bootstrapCommand(ctx context.Context, args []string, in io.Reader, out io.Writer) error {
+	if len(args) > 0 && args[0] == "help" {
+		fmt.Fprintln(out, bootstrapUsage)
+		return nil
+	}
+
+	cfg, args, err := config.New(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("bootstrap-admin: want an email and a name\n\n%s", bootstrapUsage)
+	}
+
+	password := os.Getenv("RADIUSXCTL_ADMIN_PASSWORD")
+	if password == "" {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			return fmt.Errorf("reading password from stdin: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	// The checks Register would apply, since the API is bypassed.
+	err = protovalidate.Validate(&usersv1.RegisterRequest{Email: args[0], Name: args[1], Password: password})
+	if err != nil {
+		return err
+	}
+
+	store, err := storage.New(ctx, *cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	admin, err := bootstrapAdmin(ctx, store.Users, store.Tx, &domain.User{Email: args[0], Name: args[1], Password: password})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "created admin %d <%s>\n", admin.ID, admin.Email)
+	return nil
+}
+
+func bootstrapAdmin(ctx context.Context, users port.UserRepository, tx port.Transactor, user *domain.User) (*domain.User, error) {
+	hashedPassword, err := utils.HashPassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	var admin *domain.User
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := users.AdminExists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errAdminExists
+		}
+
+		admin, err = users.CreateUser(ctx, &domain.User{Name: user.Name, Email: user.Email, Password: hashedPassword, Role: domain.Admin})
+		if errors.Is(err, domain.ErrorConflictData) {
+			return fmt.Errorf("%s is already registered", user.Email)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return admin, nil
+}
@@ -0,0 +1,103 @@
+// Command radiusxctl manages users through the users gRPC API.
+//
+//	radiusxctl [flags] users <command>          see radiusxctl users help
+//	radiusxctl bootstrap-admin <email> <name>   see radiusxctl bootstrap-admin help
+//
+// Run radiusxctl -h to list the connection flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+const usage = `usage: radiusxctl [flags] <command>
+
+commands:
+  users             create, get, list, update, delete, import and export users
+  bootstrap-admin   create the first admin directly in the database
+
+Settings are read from the YAML file given by -config or RADIUSXCTL_CONFIG,
+or radiusxctl/config.yaml in the user configuration directory, then from
+RADIUSXCTL_* variables and then from flags.`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "radiusxctl: %v\n", describe(err))
+			os.Exit(1)
+		}
+	}
+}
+
+func run(ctx context.Context, args []string, in io.Reader, out, errOut io.Writer) error {
+	s, args, err := loadSettings(args)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n\n%s", usage)
+	}
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "users":
+		conn, err := s.dial()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		users := &usersCommand{
+			client:   usersv1.NewUserServiceClient(conn),
+			settings: s,
+			in:       in,
+			out:      out,
+			errOut:   errOut,
+		}
+		return users.run(ctx, args)
+	case "bootstrap-admin":
+		return bootstrapCommand(ctx, args, in, out)
+	case "help":
+		fmt.Fprintln(out, usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+}
+
+func describe(err error) string {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err.Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", s.Code(), s.Message())
+	for _, detail := range s.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, violation := range badRequest.FieldViolations {
+			fmt.Fprintf(&b, "\n  %s: %s", violation.Field, violation.Description)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// record Password is only read, on import.
+type record struct {
+	ID              uint64     `json:"id,omitempty" yaml:"id,omitempty"`
+	Name            string     `json:"name" yaml:"name"`
+	Email           string     `json:"email" yaml:"email"`
+	Password        string     `json:"password,omitempty" yaml:"password,omitempty"`
+	Role            string     `json:"role,omitempty" yaml:"role,omitempty"`
+	CreatedAt       *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+	PasswordExpired bool       `json:"password_expired,omitempty" yaml:"password_expired,omitempty"`
+}
+
+func newRecord(user *usersv1.User) record {
+	r := record{
+		ID:              user.GetId(),
+		Name:            user.GetName(),
+		Email:           user.GetEmail(),
+		Role:            user.GetRole().String(),
+		PasswordExpired: user.GetPasswordExpired(),
+	}
+	if user.CreatedAt != nil {
+		t := user.CreatedAt.AsTime()
+		r.CreatedAt = &t
+	}
+	if user.UpdatedAt != nil {
+		t := user.UpdatedAt.AsTime()
+		r.UpdatedAt = &t
+	}
+	return r
+}
+
+func newRecords(users []*usersv1.User) []record {
+	records := make([]record, 0, len(users))
+	for _, user := range users {
+		records = append(records, newRecord(user))
+	}
+	return records
+}
+
+var printers = map[string]func(w io.Writer, v any) error{
+	"table": printTable,
+	"json": func(w io.Writer, v any) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	},
+	"yaml": func(w io.Writer, v any) error {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+		return encoder.Close()
+	},
+}
+
+func printTable(w io.Writer, v any) error {
+	var records []record
+	switch v := v.(type) {
+	case record:
+		records = []record{v}
+	case []record:
+		records = v
+	default:
+		return fmt.Errorf("cannot print %T as a table", v)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tEMAIL\tROLE\tCREATED")
+	for _, r := range records {
+		created := ""
+		if r.CreatedAt != nil {
+			created = r.CreatedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", r.ID, r.Name, r.Email, r.Role, created)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/tlsconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"gopkg.in/yaml.v3"
+)
+
+// settings are layered from defaults, the YAML file, RADIUSXCTL_* variables
+// and flags.
+type settings struct {
+	Addr string `yaml:"addr"`
+	// TLS is implied by any of the files below.
+	TLS        bool   `yaml:"tls"`
+	CAFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
+	// Timeout applies per user or page to imports and exports.
+	Timeout time.Duration `yaml:"timeout"`
+	Output  string        `yaml:"output"`
+	// UserID is sent as the caller, as the gateway would; the service only
+	// trusts it from the gateway or mTLS clients.
+	UserID uint64 `yaml:"user_id"`
+}
+
+type setting struct {
+	name    string
+	env     string
+	usage   string
+	boolean bool
+	set     func(string) error
+}
+
+func (s *settings) fields() []setting {
+	str := func(dst *string) func(string) error {
+		return func(v string) error { *dst = v; return nil }
+	}
+
+	return []setting{
+		{name: "addr", env: "RADIUSXCTL_ADDR", usage: "service address", set: str(&s.Addr)},
+		{name: "tls", env: "RADIUSXCTL_TLS", usage: "connect over TLS", boolean: true, set: func(v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return errors.New("invalid boolean")
+			}
+			s.TLS = b
+			return nil
+		}},
+		{name: "ca-file", env: "RADIUSXCTL_CA_FILE", usage: "CA bundle verifying the server, system roots if empty", set: str(&s.CAFile)},
+		{name: "cert-file", env: "RADIUSXCTL_CERT_FILE", usage: "client certificate for mTLS", set: str(&s.CertFile)},
+		{name: "key-file", env: "RADIUSXCTL_KEY_FILE", usage: "client key for mTLS", set: str(&s.KeyFile)},
+		{name: "server-name", env: "RADIUSXCTL_SERVER_NAME", usage: "name expected in the server certificate", set: str(&s.ServerName)},
+		{name: "timeout", env: "RADIUSXCTL_TIMEOUT", usage: "deadline of each call", set: func(v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return errors.New("invalid duration")
+			}
+			s.Timeout = d
+			return nil
+		}},
+		{name: "o", env: "RADIUSXCTL_OUTPUT", usage: "output format: table, json or yaml", set: str(&s.Output)},
+		{name: "user-id", env: "RADIUSXCTL_USER_ID", usage: "id of the admin making the calls, needed to invite users", set: func(v string) error {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return errors.New("invalid user id")
+			}
+			s.UserID = id
+			return nil
+		}},
+	}
+}
+
+// loadSettings falls back to radiusxctl/config.yaml in the user
+// configuration directory without -config or RADIUSXCTL_CONFIG.
+func loadSettings(args []string) (*settings, []string, error) {
+	s := &settings{
+		Addr:    "localhost:50051",
+		Timeout: 10 * time.Second,
+		Output:  "table",
+	}
+	fields := s.fields()
+
+	flags := flag.NewFlagSet("radiusxctl", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), usage)
+		fmt.Fprintln(flags.Output(), "\nflags:")
+		flags.PrintDefaults()
+	}
+	configFile := flags.String("config", os.Getenv("RADIUSXCTL_CONFIG"), "YAML settings file")
+
+	var overrides []func() error
+	for _, f := range fields {
+		define := flags.Func
+		if f.boolean {
+			define = flags.BoolFunc
+		}
+		define(f.name, f.usage+", overrides "+f.env, func(value string) error {
+			overrides = append(overrides, func() error {
+				if err := f.set(value); err != nil {
+					return fmt.Errorf("-%s: %w", f.name, err)
+				}
+				return nil
+			})
+			return nil
+		})
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	file, required := *configFile, true
+	if file == "" {
+		dir, err := os.UserConfigDir()
+		if err == nil {
+			file, required = filepath.Join(dir, "radiusxctl", "config.yaml"), false
+		}
+	}
+	if file != "" {
+		if err := s.loadFile(file, required); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var errs []error
+	for _, f := range fields {
+		value := os.Getenv(f.env)
+		if value == "" {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+		}
+	}
+	for _, override := range overrides {
+		if err := override(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return s, flags.Args(), nil
+}
+
+func (s *settings) loadFile(file string, required bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(s); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+func (s *settings) validate() error {
+	var errs []error
+	if s.Addr == "" {
+		errs = append(errs, errors.New("addr is required"))
+	}
+	if s.Timeout <= 0 {
+		errs = append(errs, errors.New("timeout must be positive"))
+	}
+	if (s.CertFile == "") != (s.KeyFile == "") {
+		errs = append(errs, errors.New("cert-file and key-file must be set together"))
+	}
+	if _, ok := printers[s.Output]; !ok {
+		errs = append(errs, fmt.Errorf("output must be one of table, json, yaml, got %q", s.Output))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *settings) dial() (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+
+	if s.TLS || s.CAFile != "" || s.CertFile != "" {
+		tlsConfig, err := tlsconfig.Client(s.CAFile, s.ServerName)
+		if err != nil {
+			return nil, err
+		}
+		if s.CertFile != "" {
+			certificate, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	return grpc.NewClient(s.Addr, grpc.WithTransportCredentials(creds))
+}
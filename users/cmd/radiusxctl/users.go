@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+const usersUsage = `usage: radiusxctl [flags] users <command>
+
+commands:
+  create -name N -email E -password P [-role R]   register a user
+  get ID                                          print a user
+  list [-page N] [-limit N]                       print a page of users
+  update ID [-name N] [-email E] [-password P] [-role R]
+                                                  change the given fields
+  delete ID                                       delete a user
+  import [-f FILE]                                create the users listed in
+                                                  FILE, or stdin, as JSON or YAML
+  export [-limit N]                               print every user
+
+Roles are reader, agent and admin. A password of - is read from the first
+line of stdin. Import takes the records export prints, plus a password for
+each user.
+
+When the service only registers invited users, create and import invite
+each user and accept the invitation for them, which needs -user-id of an
+admin.`
+
+const exportPageSize = 100
+
+type usersCommand struct {
+	client   usersv1.UserServiceClient
+	settings *settings
+	in       io.Reader
+	out      io.Writer
+	errOut   io.Writer
+}
+
+func (c *usersCommand) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing users command\n\n%s", usersUsage)
+	}
+
+	command, args := args[0], args[1:]
+
+	if c.settings.UserID != 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, api.UserIDHeader, strconv.FormatUint(c.settings.UserID, 10))
+	}
+
+	switch command {
+	case "create":
+		return c.create(ctx, args)
+	case "get":
+		return c.get(ctx, args)
+	case "list":
+		return c.list(ctx, args)
+	case "update":
+		return c.update(ctx, args)
+	case "delete":
+		return c.delete(ctx, args)
+	case "import":
+		return c.importUsers(ctx, args)
+	case "export":
+		return c.export(ctx, args)
+	case "help":
+		fmt.Fprintln(c.out, usersUsage)
+		return nil
+	default:
+		return fmt.Errorf("unknown users command %q\n\n%s", command, usersUsage)
+	}
+}
+
+func (c *usersCommand) create(ctx context.Context, args []string) error {
+	flags := newFlagSet("create")
+	name := flags.String("name", "", "full name")
+	email := flags.String("email", "", "email address")
+	password := flags.String("password", "", "password, - to read it from stdin")
+	role := flags.String("role", "", "role, reader when empty")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	r := record{Name: *name, Email: *email, Role: *role}
+
+	var err error
+	r.Password, err = c.password(*password)
+	if err != nil {
+		return err
+	}
+
+	user, err := c.register(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return c.print(newRecord(user))
+}
+
+func (c *usersCommand) get(ctx context.Context, args []string) error {
+	flags := newFlagSet("get")
+	id, err := parseID(flags, args)
+	if err != nil {
+		return err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.settings.Timeout)
+	defer cancel()
+
+	res, err := c.client.GetUser(callCtx, &usersv1.GetUserRequest{Id: id})
+	if err != nil {
+		return err
+	}
+
+	return c.print(newRecord(res.User))
+}
+
+func (c *usersCommand) list(ctx context.Context, args []string) error {
+	flags := newFlagSet("list")
+	page := flags.Uint64("page", 1, "page to print, counting from one")
+	limit := flags.Uint64("limit", 50, "users per page")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.settings.Timeout)
+	defer cancel()
+
+	res, err := c.client.ListUsers(callCtx, &usersv1.ListUsersRequest{Skip: *page, Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	return c.print(newRecords(res.User))
+}
+
+func (c *usersCommand) update(ctx context.Context, args []string) error {
+	flags := newFlagSet("update")
+	flags.String("name", "", "new full name")
+	flags.String("email", "", "new email address")
+	flags.String("password", "", "new password, - to read it from stdin")
+	flags.String("role", "", "new role")
+	id, err := parseID(flags, args)
+	if err != nil {
+		return err
+	}
+
+	req := &usersv1.UpdateUserRequest{Id: id}
+
+	// Only the flags given are sent, so the others keep their value.
+	var errs []error
+	flags.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		switch f.Name {
+		case "name":
+			req.Name = &value
+		case "email":
+			req.Email = &value
+		case "password":
+			password, err := c.password(value)
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			req.Password = &password
+		case "role":
+			role, err := parseRole(value)
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			req.Role = &role
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if req.Name == nil && req.Email == nil && req.Password == nil && req.Role == nil {
+		return errors.New("update: nothing to update, pass at least one of -name, -email, -password, -role")
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.settings.Timeout)
+	defer cancel()
+
+	res, err := c.client.UpdateUser(callCtx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.print(newRecord(res.User))
+}
+
+func (c *usersCommand) delete(ctx context.Context, args []string) error {
+	flags := newFlagSet("delete")
+	id, err := parseID(flags, args)
+	if err != nil {
+		return err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.settings.Timeout)
+	defer cancel()
+
+	_, err = c.client.DeleteUser(callCtx, &usersv1.DeleteUserRequest{Id: id})
+	return err
+}
+
+// importUsers goes on past failures, reporting them on errOut.
+func (c *usersCommand) importUsers(ctx context.Context, args []string) error {
+	flags := newFlagSet("import")
+	file := flags.String("f", "-", "file to read, - for stdin")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	in := c.in
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	// JSON is valid YAML, so one decoder reads both.
+	var records []record
+	if err := yaml.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	created := make([]record, 0, len(records))
+	var failed []string
+	for _, r := range records {
+		user, err := c.register(ctx, r)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", r.Email, describe(err)))
+			continue
+		}
+		created = append(created, newRecord(user))
+	}
+
+	if err := c.print(created); err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		for _, failure := range failed {
+			fmt.Fprintln(c.errOut, failure)
+		}
+		return fmt.Errorf("import: %d of %d users failed", len(failed), len(records))
+	}
+	return nil
+}
+
+func (c *usersCommand) export(ctx context.Context, args []string) error {
+	flags := newFlagSet("export")
+	limit := flags.Uint64("limit", exportPageSize, "users fetched per call")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if *limit == 0 {
+		return errors.New("export: -limit must be positive")
+	}
+
+	var records []record
+	for page := uint64(1); ; page++ {
+		callCtx, cancel := context.WithTimeout(ctx, c.settings.Timeout)
+		res, err := c.client.ListUsers(callCtx, &usersv1.ListUsersRequest{Skip: page, Limit: *limit})
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		records = append(records, newRecords(res.User)...)
+		if uint64(len(res.User)) < *limit {
+			break
+		}
+	}
+
+	return c.print(records)
+}
+
+// register sets any role but reader after creating the user.
+func (c *usersCommand) register(ctx context.Context, r record) (*usersv1.User, error) {
+	role, err := parseRole(r.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.settings.Timeout)
+	defer cancel()
+
+	res, err := c.client.Register(callCtx, &usersv1.RegisterRequest{
+		Name:     r.Name,
+		Email:    r.Email,
+		Password: r.Password,
+	})
+	if registrationClosed(err) {
+		return c.invite(callCtx, r, role)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if role == usersv1.Role_ROLE_UNSPECIFIED || role == res.User.GetRole() {
+		return res.User, nil
+	}
+
+	updated, err := c.client.UpdateUser(callCtx, &usersv1.UpdateUserRequest{Id: res.User.GetId(), Role: &role})
+	if err != nil {
+		return nil, fmt.Errorf("created user %d but could not set its role: %w", res.User.GetId(), err)
+	}
+
+	return updated.User, nil
+}
+
+// invite creates the user through an invitation it accepts at once.
+func (c *usersCommand) invite(ctx context.Context, r record, role usersv1.Role) (*usersv1.User, error) {
+	if role == usersv1.Role_ROLE_UNSPECIFIED {
+		role = usersv1.Role_ROLE_READER
+	}
+
+	invited, err := c.client.InviteUser(ctx, &usersv1.InviteUserRequest{Email: r.Email, Role: role})
+	if err != nil {
+		return nil, fmt.Errorf("registration is invite-only and inviting %s failed: %w", r.Email, err)
+	}
+
+	res, err := c.client.AcceptInvitation(ctx, &usersv1.AcceptInvitationRequest{
+		Token:    invited.GetToken(),
+		Name:     r.Name,
+		Password: r.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.User, nil
+}
+
+func registrationClosed(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok || s.Code() != codes.PermissionDenied {
+		return false
+	}
+	for _, detail := range s.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if ok && info.GetReason() == api.ReasonRegistrationClosed {
+			return true
+		}
+	}
+	return false
+}
+
+// password reads the first line of stdin when value is -.
+func (c *usersCommand) password(value string) (string, error) {
+	if value != "-" {
+		return value, nil
+	}
+
+	line, err := bufio.NewReader(c.in).ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("reading password from stdin: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *usersCommand) print(v any) error {
+	return printers[c.settings.Output](c.out, v)
+}
+
+// parseRole accepts any case, with or without the ROLE_ prefix.
+func parseRole(value string) (usersv1.Role, error) {
+	if value == "" {
+		return usersv1.Role_ROLE_UNSPECIFIED, nil
+	}
+
+	name := strings.ToUpper(value)
+	if !strings.HasPrefix(name, "ROLE_") {
+		name = "ROLE_" + name
+	}
+
+	role, ok := usersv1.Role_value[name]
+	if !ok || role == int32(usersv1.Role_ROLE_UNSPECIFIED) {
+		return 0, fmt.Errorf("unknown role %q, want reader, agent or admin", value)
+	}
+	return usersv1.Role(role), nil
+}
+
+func newFlagSet(command string) *flag.FlagSet {
+	return flag.NewFlagSet("users "+command, flag.ContinueOnError)
+}
+
+func parseFlags(flags *flag.FlagSet, args []string, n int) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != n {
+		return fmt.Errorf("%s: want %d arguments, got %d", flags.Name(), n, flags.NArg())
+	}
+	return nil
+}
+
+// parseID accepts the id before or after the flags.
+func parseID(flags *flag.FlagSet, args []string) (uint64, error) {
+	var value string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		value, args = args[0], args[1:]
+		if err := parseFlags(flags, args, 0); err != nil {
+			return 0, err
+		}
+	} else {
+		if err := parseFlags(flags, args, 1); err != nil {
+			return 0, err
+		}
+		value = flags.Arg(0)
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("%s: invalid user id %q", flags.Name(), value)
+	}
+	return id, nil
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/endpoint"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/memory"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/memory/repository"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/transport"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/service"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newUsersCommand serves the real service over an in-memory repository and
+// returns a command connected to it, writing to out.
+func newUsersCommand(t *testing.T, output string, in string) (*usersCommand, *bytes.Buffer, *bytes.Buffer) {
+	client := serveUsers(t, repository.NewUserRepository(), domain.RegistrationPolicy{})
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	return &usersCommand{
+		client:   client,
+		settings: &settings{Timeout: 5 * time.Second, Output: output},
+		in:       strings.NewReader(in),
+		out:      out,
+		errOut:   errOut,
+	}, out, errOut
+}
+
+func serveUsers(t *testing.T, repo *repository.UserRepository, registration domain.RegistrationPolicy) usersv1.UserServiceClient {
+	userService := service.NewUserService(repo, memory.New(1000), nil, repo, domain.PasswordPolicy{}, registration, log.NewNopLogger())
+
+	validator, err := transport.NewValidator()
+	require.NoError(t, err)
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(transport.IdentityUnaryInterceptor(), transport.ValidationUnaryInterceptor(validator)),
+		grpc.ChainStreamInterceptor(transport.IdentityStreamInterceptor(), transport.ValidationStreamInterceptor(validator)),
+	)
+	usersv1.RegisterUserServiceServer(server, transport.MakeGrpcTransport(*endpoint.MakeServerEndpoints(userService)))
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return usersv1.NewUserServiceClient(conn)
+}
+
+func runJSON(t *testing.T, c *usersCommand, out *bytes.Buffer, v any, args ...string) {
+	out.Reset()
+	require.NoError(t, c.run(context.Background(), args))
+	require.NoError(t, json.Unmarshal(out.Bytes(), v), out.String())
+}
+
+func TestUsersCommand_Lifecycle(t *testing.T) {
+	c, out, _ := newUsersCommand(t, "json", "secretpass1\n")
+
+	var created record
+	runJSON(t, c, out, &created, "create", "-name", "Ada", "-email", "ada@example.com", "-password", "-", "-role", "admin")
+	assert.Equal(t, uint64(1), created.ID)
+	assert.Equal(t, "ROLE_ADMIN", created.Role)
+	assert.Empty(t, created.Password)
+
+	var updated record
+	runJSON(t, c, out, &updated, "update", "1", "-name", "Ada Lovelace")
+	assert.Equal(t, "Ada Lovelace", updated.Name)
+	assert.Equal(t, "ada@example.com", updated.Email, "Unset field changed")
+	assert.Equal(t, "ROLE_ADMIN", updated.Role, "Unset role changed")
+
+	var got record
+	runJSON(t, c, out, &got, "get", "1")
+	assert.Equal(t, "Ada Lovelace", got.Name)
+
+	var listed []record
+	runJSON(t, c, out, &listed, "list")
+	require.Len(t, listed, 1)
+	assert.Equal(t, "Ada Lovelace", listed[0].Name)
+
+	require.NoError(t, c.run(context.Background(), []string{"delete", "1"}))
+	err := c.run(context.Background(), []string{"get", "1"})
+	assert.Equal(t, "NotFound: data not found", describe(err))
+}
+
+func TestUsersCommand_InviteOnly(t *testing.T) {
+	repo := repository.NewUserRepository()
+	admin, err := repo.CreateUser(context.Background(), &domain.User{Name: "Root", Email: "root@example.com", Password: "hash", Role: domain.Admin})
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	c := &usersCommand{
+		client:   serveUsers(t, repo, domain.RegistrationPolicy{InviteOnly: true, InvitationTTL: time.Hour}),
+		settings: &settings{Timeout: 5 * time.Second, Output: "json"},
+		in:       strings.NewReader(""),
+		out:      out,
+		errOut:   &bytes.Buffer{},
+	}
+
+	err = c.run(context.Background(), []string{"create", "-name", "Ada", "-email", "ada@example.com", "-password", "secretpass1", "-role", "agent"})
+	assert.ErrorContains(t, err, "registration is invite-only", "Invited without a caller")
+
+	c.settings.UserID = admin.ID
+	var created record
+	runJSON(t, c, out, &created, "create", "-name", "Ada", "-email", "ada@example.com", "-password", "secretpass1", "-role", "agent")
+	assert.Equal(t, "ada@example.com", created.Email)
+	assert.Equal(t, "ROLE_AGENT", created.Role)
+}
+
+func TestUsersCommand_ImportExport(t *testing.T) {
+	input := `
+- name: Ada
+  email: ada@example.com
+  password: secretpass1
+  role: admin
+- name: Grace
+  email: grace@example.com
+  password: secretpass2
+- name: Duplicate
+  email: ada@example.com
+  password: secretpass3
+`
+	c, out, errOut := newUsersCommand(t, "yaml", input)
+
+	err := c.run(context.Background(), []string{"import"})
+	assert.EqualError(t, err, "import: 1 of 3 users failed")
+	assert.Contains(t, errOut.String(), "ada@example.com: AlreadyExists")
+
+	c.settings.Output = "json"
+
+	var exported []record
+	runJSON(t, c, out, &exported, "export", "-limit", "1")
+	require.Len(t, exported, 2)
+	assert.Equal(t, "ada@example.com", exported[0].Email)
+	assert.Equal(t, "ROLE_ADMIN", exported[0].Role)
+	assert.Equal(t, "grace@example.com", exported[1].Email)
+	assert.Equal(t, "ROLE_READER", exported[1].Role)
+}
+
+func TestUsersCommand_Table(t *testing.T) {
+	c, out, _ := newUsersCommand(t, "table", "")
+
+	require.NoError(t, c.run(context.Background(), []string{"create", "-name", "Ada", "-email", "ada@example.com", "-password", "secretpass1"}))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"ID", "NAME", "EMAIL", "ROLE", "CREATED"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"1", "Ada", "ada@example.com", "ROLE_READER"}, strings.Fields(lines[1])[:4])
+}
+
+func TestUsersCommand_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "MissingCommand", want: "missing users command"},
+		{name: "UnknownCommand", args: []string{"rename"}, want: `unknown users command "rename"`},
+		{name: "InvalidID", args: []string{"get", "abc"}, want: `users get: invalid user id "abc"`},
+		{name: "NothingToUpdate", args: []string{"update", "1"}, want: "nothing to update"},
+		{name: "UnknownRole", args: []string{"update", "1", "-role", "owner"}, want: `unknown role "owner"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := newUsersCommand(t, "table", "")
+			err := c.run(context.Background(), tt.args)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	for value, want := range map[string]usersv1.Role{
+		"":           usersv1.Role_ROLE_UNSPECIFIED,
+		"reader":     usersv1.Role_ROLE_READER,
+		"Agent":      usersv1.Role_ROLE_AGENT,
+		"ROLE_ADMIN": usersv1.Role_ROLE_ADMIN,
+	} {
+		role, err := parseRole(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, role, value)
+	}
+
+	_, err := parseRole("unspecified")
+	assert.Error(t, err)
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := storage.New(ctx, config.DB{Connection: "sqlite", Name: filepath.Join(t.TempDir(), "users.db")})
+	require.NoError(t, err)
+	t.Cleanup(store.Close)
+	require.NoError(t, store.Migrate())
+
+	admin, err := bootstrapAdmin(ctx, store.Users, store.Tx, &domain.User{Name: "Root", Email: "root@example.com", Password: "secretpass1"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.Admin, admin.Role)
+	assert.NotEqual(t, "secretpass1", admin.Password, "Password stored in clear")
+
+	_, err = bootstrapAdmin(ctx, store.Users, store.Tx, &domain.User{Name: "Other", Email: "other@example.com", Password: "secretpass1"})
+	assert.ErrorIs(t, err, errAdminExists)
+
+	_, err = store.Users.GetUserByEmail(ctx, "other@example.com")
+	assert.ErrorIs(t, err, domain.ErrorDataNotFound, "Refused admin was created")
+}
+
+func TestUsersCommand_FieldViolations(t *testing.T) {
+	c, _, _ := newUsersCommand(t, "table", "")
+
+	err := c.run(context.Background(), []string{"create", "-name", "Ada", "-email", "not-an-email", "-password", "secretpass1"})
+	require.Error(t, err)
+
+	lines := strings.Split(describe(err), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "InvalidArgument: invalid request", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "  email: "), lines[1])
+}
@@ -0,0 +1,332 @@
+// Command users serves the users gRPC API and manages its database schema.
+//
+//	users [serve] [flags]          serve the API
+//	users migrate [flags] <cmd>    manage the schema, see users migrate help
+//	users config [flags]           print the effective configuration
+//
+// Run users <command> -h to list the configuration flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/endpoint"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/health"
+	logpublisher "github.com/OzkrOssa/radiusx-users/internal/adapter/publisher/log"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/failopen"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/invalidation"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/memory"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/redis"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/tlsconfig"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/transport"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/OzkrOssa/radiusx-users/internal/core/service"
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func main() {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	logger = log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
+
+	if err := run(os.Args[1:], logger); err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, logger log.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	if command == "migrate" && (len(args) == 0 || args[0] == "help") {
+		fmt.Fprintln(os.Stdout, migrateUsage)
+		return nil
+	}
+
+	cfg, args, err := config.New(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	switch command {
+	case "serve":
+		return serve(ctx, cfg, logger)
+	case "migrate":
+		return migrateCommand(ctx, cfg, args, os.Stdout)
+	case "config":
+		fmt.Fprint(os.Stdout, cfg)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func serve(ctx context.Context, cfg *config.Container, logger log.Logger) error {
+	if !cfg.Transport.CallerTrusted() {
+		return errors.New("x-user-id can be forged by any client: require client certificates with transport.tls_client_ca_file, or set transport.trusted_gateway (TRANSPORT_TRUSTED_GATEWAY=true) if only the gateway can reach the port; see config.example.yaml")
+	}
+
+	store, err := storage.New(ctx, *cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if cfg.DB.AutoMigrate {
+		if err := store.Migrate(); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+	}
+
+	cache, redisCache, err := newCache(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+
+	checks := []health.Check{{Name: "database", Critical: true, Probe: store.Ping}}
+	if redisCache != nil {
+		// A fail-open cache keeps the service answering without Redis.
+		checks = append(checks, health.Check{Name: "redis", Critical: cfg.Redis.FailurePolicy == "closed", Probe: redisCache.Ping})
+	}
+	checker := health.New(logger, cfg.Health.Timeout, []string{usersv1.UserService_ServiceDesc.ServiceName}, checks...)
+	go checker.Run(ctx, cfg.Health.Interval)
+
+	passwordPolicy := domain.PasswordPolicy{
+		HistorySize: cfg.Password.HistorySize,
+		MaxAge: map[domain.Role]time.Duration{
+			domain.Admin:  cfg.Password.MaxAgeAdmin,
+			domain.Agent:  cfg.Password.MaxAgeAgent,
+			domain.Reader: cfg.Password.MaxAgeReader,
+		},
+	}
+	registration := domain.RegistrationPolicy{
+		InviteOnly:    cfg.Registration.InviteOnly,
+		InvitationTTL: cfg.Registration.InvitationTTL,
+	}
+	publisher, closePublisher, err := newPublisher(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closePublisher()
+
+	// The relay is stopped before the store and publisher it uses close.
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		_ = service.NewOutboxRelay(store.Outbox, publisher, logger).Run(relayCtx)
+	}()
+	defer func() {
+		stopRelay()
+		<-relayDone
+	}()
+
+	if cfg.Events.Retention > 0 {
+		go func() {
+			_ = service.NewUserEventPruner(store.Events, cfg.Events.Retention, logger).Run(relayCtx)
+		}()
+	}
+
+	userService := service.NewUserService(store.Users, cache, store.Events, store.Tx, passwordPolicy, registration, logger)
+	chain := endpoint.Chain{
+		Logger:      logger,
+		Instruments: endpoint.NewPrometheusInstruments(prometheus.DefaultRegisterer),
+		Default: endpoint.Policy{
+			Timeout:       cfg.Transport.RequestTimeout,
+			MaxConcurrent: cfg.Transport.MaxConcurrentRequests,
+		},
+		Policies: map[string]endpoint.Policy{
+			"WatchUsers": {MaxConcurrent: cfg.Transport.MaxConcurrentStreams},
+		},
+	}
+	endpoints := chain.Apply(endpoint.MakeServerEndpoints(userService))
+
+	validator, err := transport.NewValidator()
+	if err != nil {
+		return fmt.Errorf("validator: %w", err)
+	}
+
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		transport.UnaryServerInterceptor(logger),
+		transport.IdentityUnaryInterceptor(),
+		transport.ValidationUnaryInterceptor(validator),
+	}
+	if cfg.Transport.IdempotencyWindow > 0 {
+		unaryInterceptors = append(unaryInterceptors, transport.IdempotencyUnaryInterceptor(cache, cfg.Transport.IdempotencyWindow))
+	}
+
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(
+			transport.StreamServerInterceptor(logger),
+			transport.IdentityStreamInterceptor(),
+			transport.ValidationStreamInterceptor(validator),
+		),
+	}
+	if cfg.Transport.TLSCertFile != "" {
+		certificates, err := tlsconfig.NewServer(cfg.Transport, logger)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		go certificates.Run(ctx, cfg.Transport.TLSReloadInterval)
+
+		options = append(options, grpc.Creds(credentials.NewTLS(certificates.Config())))
+	}
+
+	server := grpc.NewServer(options...)
+	usersv1.RegisterUserServiceServer(server, transport.MakeGrpcTransport(*endpoints))
+	healthpb.RegisterHealthServer(server, checker.Server())
+
+	var probes *http.Server
+	if cfg.Health.Port != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/", checker.Handler())
+		mux.Handle("GET /metrics", promhttp.Handler())
+
+		probes = &http.Server{
+			Addr:              net.JoinHostPort(cfg.Health.Host, strconv.Itoa(cfg.Health.Port)),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			level.Info(logger).Log("msg", "serving health probes", "addr", probes.Addr)
+			err := probes.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				level.Error(logger).Log("msg", "health probes stopped", "err", err)
+			}
+		}()
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(cfg.Transport.Host, strconv.Itoa(cfg.Transport.Port)))
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		// Readiness stays down for DrainDelay before calls are refused.
+		checker.Drain()
+		time.Sleep(cfg.Health.DrainDelay)
+
+		timer := time.AfterFunc(cfg.Transport.ShutdownTimeout, server.Stop)
+		defer timer.Stop()
+
+		server.GracefulStop()
+
+		if probes != nil {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Transport.ShutdownTimeout)
+			defer cancel()
+			_ = probes.Shutdown(shutdownCtx)
+		}
+	}()
+
+	level.Info(logger).Log("msg", "serving gRPC", "addr", listener.Addr(), "tls", cfg.Transport.TLSCertFile != "", "client_auth", cfg.Transport.TLSClientCAFile != "")
+
+	err = server.Serve(listener)
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// newCache returns a nil Redis client for the memory backend.
+func newCache(ctx context.Context, cfg *config.Container, logger log.Logger) (port.CacheRepository, *redis.Redis, error) {
+	if cfg.Cache.Backend == "memory" {
+		return memory.New(cfg.Cache.Size), nil, nil
+	}
+
+	// A fail-open cache must not keep the service from booting.
+	var redisCache *redis.Redis
+	var err error
+	if cfg.Redis.FailurePolicy == "open" {
+		redisCache, err = redis.NewLazy(cfg.Redis)
+	} else {
+		redisCache, err = redis.New(ctx, cfg.Redis)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var cache port.CacheRepository = redisCache
+	if cfg.Redis.FailurePolicy == "open" {
+		cache = failopen.New(cache, logger)
+	}
+
+	if cfg.Cache.Backend == "tiered" {
+		local, err := newLocalCache(ctx, cfg, logger)
+		if err != nil {
+			redisCache.Close()
+			return nil, nil, err
+		}
+		cache = memory.NewTiered(local, cache, cfg.Cache.LocalTTL, utils.GenerateCacheKey("user", ""))
+	}
+	return cache, redisCache, nil
+}
+
+// newLocalCache goes without the invalidation bus when Redis is down and the
+// cache fails open, as local entries expire within cache.local_ttl anyway.
+func newLocalCache(ctx context.Context, cfg *config.Container, logger log.Logger) (port.CacheRepository, error) {
+	local := memory.New(cfg.Cache.Size)
+
+	bus, err := redis.NewInvalidationBus(ctx, cfg.Redis)
+	if err == nil {
+		var cache port.CacheRepository
+		cache, err = invalidation.New(ctx, local, bus, logger)
+		if err == nil {
+			if cfg.Redis.FailurePolicy == "open" {
+				cache = failopen.New(cache, logger)
+			}
+			return cache, nil
+		}
+		bus.Close()
+	}
+
+	if cfg.Redis.FailurePolicy != "open" {
+		return nil, err
+	}
+	level.Warn(logger).Log("msg", "cache invalidation bus unavailable, local entries expire on their own", "err", err)
+	return local, nil
+}
+
+func newPublisher(cfg *config.Container, logger log.Logger) (port.EventPublisher, func() error, error) {
+	if cfg.Events.Publisher == "log" {
+		return logpublisher.New(logger), func() error { return nil }, nil
+	}
+
+	publisher, err := redis.NewEventPublisher(cfg.Redis, cfg.Events.Stream, cfg.Events.StreamMaxLen)
+	if err != nil {
+		return nil, nil, err
+	}
+	return publisher, publisher.Close, nil
+}
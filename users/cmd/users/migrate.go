@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/migration"
+)
+
+const migrateUsage = `usage: users migrate [flags] <command>
+
+Manages the schema of the database selected by DB_CONNECTION with the
+migrations built into the binary. Set DB_AUTO_MIGRATE=false to stop the
+server from applying them on boot.
+
+commands:
+  status    list the migrations and which are applied
+  up        apply every pending migration
+  down N    roll back the last N applied migrations
+  goto V    migrate up or down to version V
+  force V   record version V as applied without running anything and clear
+            the dirty flag left by a failed migration; -1 records none`
+
+func migrateCommand(ctx context.Context, cfg *config.Container, args []string, out io.Writer) error {
+	store, err := storage.New(ctx, *cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	migrator, err := store.Migrator()
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	return runMigrate(migrator, args, out)
+}
+
+func runMigrate(migrator *migration.Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n\n%s", migrateUsage)
+	}
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "status":
+		if err := expectArgs(command, args, 0); err != nil {
+			return err
+		}
+	case "up":
+		if err := expectArgs(command, args, 0); err != nil {
+			return err
+		}
+		if err := migrator.Up(); err != nil {
+			return err
+		}
+	case "down":
+		if err := expectArgs(command, args, 1); err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("down: invalid number of migrations %q", args[0])
+		}
+		if err := migrator.Down(n); err != nil {
+			return err
+		}
+	case "goto":
+		if err := expectArgs(command, args, 1); err != nil {
+			return err
+		}
+		version, err := strconv.ParseUint(args[0], 10, 0)
+		if err != nil {
+			return fmt.Errorf("goto: invalid version %q", args[0])
+		}
+		if err := migrator.Goto(uint(version)); err != nil {
+			return err
+		}
+	case "force":
+		if err := expectArgs(command, args, 1); err != nil {
+			return err
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < -1 {
+			return fmt.Errorf("force: invalid version %q", args[0])
+		}
+		if err := migrator.Force(version); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", command, migrateUsage)
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	return printStatus(out, status)
+}
+
+func expectArgs(command string, args []string, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("%s: expected %d argument(s), got %d", command, n, len(args))
+	}
+	return nil
+}
+
+func printStatus(out io.Writer, status *migration.Status) error {
+	version := strconv.FormatUint(uint64(status.Version), 10)
+	if status.Version == 0 {
+		version = "none"
+	}
+	if status.Dirty {
+		version += " (dirty: fix the schema by hand, then force a version)"
+	}
+	fmt.Fprintf(out, "version: %s\n\n", version)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Applied {
+			state = "applied"
+		}
+		if status.Dirty && m.Version == status.Version {
+			state = "dirty"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Identifier, state)
+	}
+	return w.Flush()
+}
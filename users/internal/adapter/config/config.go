@@ -1,75 +1,493 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
 type (
 	Container struct {
-		App       *App
-		DB        *DB
-		Redis     *Redis
-		Transport *Transport
+		App          *App          `yaml:"app"`
+		DB           *DB           `yaml:"db"`
+		Redis        *Redis        `yaml:"redis"`
+		Cache        *Cache        `yaml:"cache"`
+		Transport    *Transport    `yaml:"transport"`
+		Health       *Health       `yaml:"health"`
+		Password     *Password     `yaml:"password"`
+		Registration *Registration `yaml:"registration"`
+		Events       *Events       `yaml:"events"`
 	}
 	App struct {
-		Env  string
-		Name string
+		Env  string `yaml:"env"`
+		Name string `yaml:"name"`
 	}
 	DB struct {
-		Connection string
-		Host       string
-		Port       string
-		Name       string
-		User       string
-		Password   string
+		// Connection is "postgres" or "sqlite", which only uses Name as a path.
+		Connection  string `yaml:"connection"`
+		Host        string `yaml:"host"`
+		Port        int    `yaml:"port"`
+		Name        string `yaml:"name"`
+		User        string `yaml:"user"`
+		Password    string `yaml:"password"`
+		AutoMigrate bool   `yaml:"auto_migrate"`
+		SSLMode     string `yaml:"sslmode"`
+		// SSLRootCert falls back to the system roots when empty.
+		SSLRootCert string `yaml:"sslrootcert"`
 	}
 	Redis struct {
-		Host     string
-		Port     string
-		Password string
+		Host     string `yaml:"host"`
+		Port     int    `yaml:"port"`
+		Password string `yaml:"password"`
+		// FailurePolicy is "open" to bypass cache failures, or "closed".
+		FailurePolicy string `yaml:"failure_policy"`
+		TLS           bool   `yaml:"tls"`
+		TLSCAFile     string `yaml:"tls_ca_file"`
+	}
+	Cache struct {
+		// Backend is "redis", "memory", or "tiered" to keep single users in
+		// process for at most LocalTTL in front of Redis.
+		Backend  string        `yaml:"backend"`
+		Size     int           `yaml:"size"`
+		LocalTTL time.Duration `yaml:"local_ttl"`
 	}
 	Transport struct {
-		Env  string
-		Host string
-		Port string
+		Env             string        `yaml:"-"`
+		Host            string        `yaml:"host"`
+		Port            int           `yaml:"port"`
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+		// TLS files are read again when they change.
+		TLSCertFile     string `yaml:"tls_cert_file"`
+		TLSKeyFile      string `yaml:"tls_key_file"`
+		TLSClientCAFile string `yaml:"tls_client_ca_file"`
+		// TLSClientAuth is "require" or "verify_if_given".
+		TLSClientAuth string `yaml:"tls_client_auth"`
+		// TrustedGateway trusts x-user-id without client certificates.
+		TrustedGateway    bool          `yaml:"trusted_gateway"`
+		TLSReloadInterval time.Duration `yaml:"tls_reload_interval"`
+		// RequestTimeout does not apply to WatchUsers streams.
+		RequestTimeout time.Duration `yaml:"request_timeout"`
+		// MaxConcurrentRequests is per RPC; zero is no cap.
+		MaxConcurrentRequests int `yaml:"max_concurrent_requests"`
+		MaxConcurrentStreams  int `yaml:"max_concurrent_streams"`
+		// IdempotencyWindow zero ignores idempotency keys.
+		IdempotencyWindow time.Duration `yaml:"idempotency_window"`
+	}
+	Health struct {
+		Host string `yaml:"host"`
+		// Port zero disables HTTP /healthz, /readyz and /metrics.
+		Port       int           `yaml:"port"`
+		Interval   time.Duration `yaml:"interval"`
+		Timeout    time.Duration `yaml:"timeout"`
+		DrainDelay time.Duration `yaml:"drain_delay"`
+	}
+	Password struct {
+		// HistorySize counts the current password; zero allows reuse.
+		HistorySize int `yaml:"history_size"`
+		// MaxAge* zero never expires passwords.
+		MaxAgeAdmin  time.Duration `yaml:"max_age_admin"`
+		MaxAgeAgent  time.Duration `yaml:"max_age_agent"`
+		MaxAgeReader time.Duration `yaml:"max_age_reader"`
+	}
+	Registration struct {
+		InviteOnly    bool          `yaml:"invite_only"`
+		InvitationTTL time.Duration `yaml:"invitation_ttl"`
+	}
+	Events struct {
+		// Publisher is "redis" or "log".
+		Publisher    string `yaml:"publisher"`
+		Stream       string `yaml:"stream"`
+		StreamMaxLen int    `yaml:"stream_max_len"`
+		// Retention is how long WatchUsers can resume from; zero keeps
+		// user events forever.
+		Retention time.Duration `yaml:"retention"`
 	}
 )
 
-func New() (*Container, error) {
+// field key is also the flag name.
+type field struct {
+	key   string
+	env   string
+	value any
+	// secret settings are redacted, read from env+"_FILE" too, and have no
+	// flag so they stay out of ps.
+	secret bool
+}
+
+func defaults() *Container {
+	return &Container{
+		App: &App{
+			Env:  "dev",
+			Name: "users",
+		},
+		DB: &DB{
+			Connection:  "postgres",
+			Port:        5432,
+			AutoMigrate: true,
+			SSLMode:     "disable",
+		},
+		Redis: &Redis{
+			Host:          "localhost",
+			Port:          6379,
+			FailurePolicy: "closed",
+		},
+		Cache: &Cache{
+			Backend:  "redis",
+			Size:     10000,
+			LocalTTL: 5 * time.Second,
+		},
+		Transport: &Transport{
+			Port:              50051,
+			ShutdownTimeout:   10 * time.Second,
+			TLSClientAuth:     "require",
+			TLSReloadInterval: 30 * time.Second,
+			RequestTimeout:    10 * time.Second,
+			IdempotencyWindow: 24 * time.Hour,
+		},
+		Health: &Health{
+			Port:     8081,
+			Interval: 10 * time.Second,
+			Timeout:  2 * time.Second,
+		},
+		Password: &Password{
+			HistorySize: 5,
+			MaxAgeAdmin: 90 * 24 * time.Hour,
+		},
+		Registration: &Registration{
+			InvitationTTL: 72 * time.Hour,
+		},
+		Events: &Events{
+			Publisher:    "redis",
+			Stream:       "users:events",
+			StreamMaxLen: 100000,
+			Retention:    30 * 24 * time.Hour,
+		},
+	}
+}
+
+func (c *Container) fields() []field {
+	return []field{
+		{key: "app.env", env: "APP_ENV", value: &c.App.Env},
+		{key: "app.name", env: "APP_NAME", value: &c.App.Name},
+		{key: "db.connection", env: "DB_CONNECTION", value: &c.DB.Connection},
+		{key: "db.host", env: "DB_HOST", value: &c.DB.Host},
+		{key: "db.port", env: "DB_PORT", value: &c.DB.Port},
+		{key: "db.name", env: "DB_NAME", value: &c.DB.Name},
+		{key: "db.user", env: "DB_USER", value: &c.DB.User},
+		{key: "db.password", env: "DB_PASSWORD", value: &c.DB.Password, secret: true},
+		{key: "db.auto_migrate", env: "DB_AUTO_MIGRATE", value: &c.DB.AutoMigrate},
+		{key: "db.sslmode", env: "DB_SSLMODE", value: &c.DB.SSLMode},
+		{key: "db.sslrootcert", env: "DB_SSLROOTCERT", value: &c.DB.SSLRootCert},
+		{key: "redis.host", env: "REDIS_ADDRESS", value: &c.Redis.Host},
+		{key: "redis.port", env: "REDIS_PORT", value: &c.Redis.Port},
+		{key: "redis.password", env: "REDIS_PASSWORD", value: &c.Redis.Password, secret: true},
+		{key: "redis.failure_policy", env: "REDIS_FAILURE_POLICY", value: &c.Redis.FailurePolicy},
+		{key: "redis.tls", env: "REDIS_TLS", value: &c.Redis.TLS},
+		{key: "redis.tls_ca_file", env: "REDIS_TLS_CA_FILE", value: &c.Redis.TLSCAFile},
+		{key: "cache.backend", env: "CACHE_BACKEND", value: &c.Cache.Backend},
+		{key: "cache.size", env: "CACHE_SIZE", value: &c.Cache.Size},
+		{key: "cache.local_ttl", env: "CACHE_LOCAL_TTL", value: &c.Cache.LocalTTL},
+		{key: "transport.host", env: "TRANSPORT_HOST", value: &c.Transport.Host},
+		{key: "transport.port", env: "TRANSPORT_PORT", value: &c.Transport.Port},
+		{key: "transport.shutdown_timeout", env: "TRANSPORT_SHUTDOWN_TIMEOUT", value: &c.Transport.ShutdownTimeout},
+		{key: "transport.tls_cert_file", env: "TRANSPORT_TLS_CERT_FILE", value: &c.Transport.TLSCertFile},
+		{key: "transport.tls_key_file", env: "TRANSPORT_TLS_KEY_FILE", value: &c.Transport.TLSKeyFile},
+		{key: "transport.tls_client_ca_file", env: "TRANSPORT_TLS_CLIENT_CA_FILE", value: &c.Transport.TLSClientCAFile},
+		{key: "transport.tls_client_auth", env: "TRANSPORT_TLS_CLIENT_AUTH", value: &c.Transport.TLSClientAuth},
+		{key: "transport.trusted_gateway", env: "TRANSPORT_TRUSTED_GATEWAY", value: &c.Transport.TrustedGateway},
+		{key: "transport.tls_reload_interval", env: "TRANSPORT_TLS_RELOAD_INTERVAL", value: &c.Transport.TLSReloadInterval},
+		{key: "transport.request_timeout", env: "TRANSPORT_REQUEST_TIMEOUT", value: &c.Transport.RequestTimeout},
+		{key: "transport.max_concurrent_requests", env: "TRANSPORT_MAX_CONCURRENT_REQUESTS", value: &c.Transport.MaxConcurrentRequests},
+		{key: "transport.max_concurrent_streams", env: "TRANSPORT_MAX_CONCURRENT_STREAMS", value: &c.Transport.MaxConcurrentStreams},
+		{key: "transport.idempotency_window", env: "TRANSPORT_IDEMPOTENCY_WINDOW", value: &c.Transport.IdempotencyWindow},
+		{key: "health.host", env: "HEALTH_HOST", value: &c.Health.Host},
+		{key: "health.port", env: "HEALTH_PORT", value: &c.Health.Port},
+		{key: "health.interval", env: "HEALTH_INTERVAL", value: &c.Health.Interval},
+		{key: "health.timeout", env: "HEALTH_TIMEOUT", value: &c.Health.Timeout},
+		{key: "health.drain_delay", env: "HEALTH_DRAIN_DELAY", value: &c.Health.DrainDelay},
+		{key: "password.history_size", env: "PASSWORD_HISTORY_SIZE", value: &c.Password.HistorySize},
+		{key: "password.max_age_admin", env: "PASSWORD_MAX_AGE_ADMIN", value: &c.Password.MaxAgeAdmin},
+		{key: "password.max_age_agent", env: "PASSWORD_MAX_AGE_AGENT", value: &c.Password.MaxAgeAgent},
+		{key: "password.max_age_reader", env: "PASSWORD_MAX_AGE_READER", value: &c.Password.MaxAgeReader},
+		{key: "registration.invite_only", env: "REGISTRATION_INVITE_ONLY", value: &c.Registration.InviteOnly},
+		{key: "registration.invitation_ttl", env: "REGISTRATION_INVITATION_TTL", value: &c.Registration.InvitationTTL},
+		{key: "events.publisher", env: "EVENTS_PUBLISHER", value: &c.Events.Publisher},
+		{key: "events.stream", env: "EVENTS_STREAM", value: &c.Events.Stream},
+		{key: "events.stream_max_len", env: "EVENTS_STREAM_MAX_LEN", value: &c.Events.StreamMaxLen},
+		{key: "events.retention", env: "EVENTS_RETENTION", value: &c.Events.Retention},
+	}
+}
+
+// New layers defaults, the YAML file, environment variables and the flags in
+// args, returning the arguments left after the flags.
+func New(args []string) (*Container, []string, error) {
 	if os.Getenv("APP_ENV") != "prod" {
-		if err := godotenv.Load(); err != nil {
-			return nil, err
+		if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, err
+		}
+	}
+
+	c := defaults()
+	fields := c.fields()
+
+	flags := flag.NewFlagSet("users", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file")
+
+	var overrides []func() error
+	for _, f := range fields {
+		if f.secret {
+			continue
 		}
+		flags.Func(f.key, "overrides "+f.env, func(value string) error {
+			overrides = append(overrides, func() error {
+				if err := f.set(value); err != nil {
+					return fmt.Errorf("-%s: %w", f.key, err)
+				}
+				return nil
+			})
+			return nil
+		})
 	}
 
-	app := &App{
-		Env:  os.Getenv("APP_ENV"),
-		Name: os.Getenv("APP_NAME"),
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
 	}
-	redis := &Redis{
-		Host:     os.Getenv("REDIS_ADDRESS"),
-		Port:     os.Getenv("REDIS_PORT"),
-		Password: os.Getenv("REDIS_PASSWORD"),
+
+	if *configFile != "" {
+		if err := c.loadFile(*configFile); err != nil {
+			return nil, nil, err
+		}
 	}
-	db := &DB{
-		Connection: os.Getenv("DB_CONNECTION"),
-		Host:       os.Getenv("DB_HOST"),
-		Port:       os.Getenv("DB_PORT"),
-		Name:       os.Getenv("DB_NAME"),
-		User:       os.Getenv("DB_USER"),
-		Password:   os.Getenv("DB_PASSWORD"),
+
+	var errs []error
+	for _, f := range fields {
+		if err := f.loadEnv(); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	transport := &Transport{
-		Env:  os.Getenv("APP_ENV"),
-		Host: os.Getenv("TRANSPORT_HOST"),
-		Port: os.Getenv("TRANSPORT_PORT"),
+	for _, override := range overrides {
+		if err := override(); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return &Container{
-		App:       app,
-		DB:        db,
-		Redis:     redis,
-		Transport: transport,
-	}, nil
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	c.Transport.Env = c.App.Env
+
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return c, flags.Args(), nil
+}
+
+func (c *Container) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(c)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (f field) loadEnv() error {
+	value, ok := os.LookupEnv(f.env)
+
+	if file, fileOk := os.LookupEnv(f.env + "_FILE"); f.secret && fileOk {
+		if ok {
+			return fmt.Errorf("%s and %s_FILE are both set", f.env, f.env)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("%s_FILE: %w", f.env, err)
+		}
+		value, ok = strings.TrimRight(string(data), "\r\n"), true
+	}
+
+	// Empty variables count as unset, as compose files often leave them.
+	if !ok || value == "" {
+		return nil
+	}
+
+	if err := f.set(value); err != nil {
+		return fmt.Errorf("%s: %w", f.env, err)
+	}
+	return nil
+}
+
+func (f field) set(value string) error {
+	switch v := f.value.(type) {
+	case *string:
+		*v = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*v = n
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*v = b
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		*v = d
+	}
+	return nil
+}
+
+func (f field) String() string {
+	value := reflect.ValueOf(f.value).Elem()
+	if f.secret && !value.IsZero() {
+		return "REDACTED"
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+func (c *Container) Validate() error {
+	var errs []error
+
+	required := func(key, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", key))
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			errs = append(errs, fmt.Errorf("%s: must be one of %s, got %q", key, strings.Join(allowed, ", "), value))
+		}
+	}
+	port := func(key string, value int) {
+		if value < 1 || value > 65535 {
+			errs = append(errs, fmt.Errorf("%s: %d is not a valid port", key, value))
+		}
+	}
+
+	switch c.DB.Connection {
+	case "postgres", "postgresql":
+		required("db.host", c.DB.Host)
+		port("db.port", c.DB.Port)
+		required("db.name", c.DB.Name)
+		required("db.user", c.DB.User)
+		// The migrations use lib/pq, which has no allow or prefer mode.
+		oneOf("db.sslmode", c.DB.SSLMode, "disable", "require", "verify-ca", "verify-full")
+	case "sqlite":
+		required("db.name", c.DB.Name)
+	default:
+		errs = append(errs, fmt.Errorf("db.connection: unsupported database %q", c.DB.Connection))
+	}
+
+	required("redis.host", c.Redis.Host)
+	port("redis.port", c.Redis.Port)
+	oneOf("redis.failure_policy", c.Redis.FailurePolicy, "open", "closed")
+
+	if c.Redis.TLSCAFile != "" && !c.Redis.TLS {
+		errs = append(errs, fmt.Errorf("redis.tls_ca_file is set but redis.tls is off"))
+	}
+
+	oneOf("cache.backend", c.Cache.Backend, "redis", "memory", "tiered")
+	if c.Cache.Size < 0 {
+		errs = append(errs, fmt.Errorf("cache.size must not be negative"))
+	}
+	if c.Cache.Backend == "tiered" && c.Cache.LocalTTL <= 0 {
+		errs = append(errs, fmt.Errorf("cache.local_ttl must be positive"))
+	}
+
+	port("transport.port", c.Transport.Port)
+	if (c.Transport.TLSCertFile == "") != (c.Transport.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("transport.tls_cert_file and transport.tls_key_file must be set together"))
+	}
+	if c.Transport.TLSClientCAFile != "" {
+		if c.Transport.TLSCertFile == "" {
+			errs = append(errs, fmt.Errorf("transport.tls_client_ca_file requires transport.tls_cert_file"))
+		}
+		oneOf("transport.tls_client_auth", c.Transport.TLSClientAuth, "require", "verify_if_given")
+	}
+	if c.Transport.TLSCertFile != "" && c.Transport.TLSReloadInterval <= 0 {
+		errs = append(errs, fmt.Errorf("transport.tls_reload_interval must be positive"))
+	}
+	if c.Transport.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("transport.shutdown_timeout must not be negative"))
+	}
+	if c.Transport.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("transport.request_timeout must not be negative"))
+	}
+	if c.Transport.MaxConcurrentRequests < 0 || c.Transport.MaxConcurrentStreams < 0 {
+		errs = append(errs, fmt.Errorf("transport.max_concurrent_requests and transport.max_concurrent_streams must not be negative"))
+	}
+	if c.Transport.IdempotencyWindow < 0 {
+		errs = append(errs, fmt.Errorf("transport.idempotency_window must not be negative"))
+	}
+
+	if c.Health.Port != 0 {
+		port("health.port", c.Health.Port)
+	}
+	if c.Health.Interval <= 0 || c.Health.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("health.interval and health.timeout must be positive"))
+	}
+	if c.Health.DrainDelay < 0 {
+		errs = append(errs, fmt.Errorf("health.drain_delay must not be negative"))
+	}
+
+	if c.Password.HistorySize < 0 {
+		errs = append(errs, fmt.Errorf("password.history_size must not be negative"))
+	}
+	if c.Password.MaxAgeAdmin < 0 || c.Password.MaxAgeAgent < 0 || c.Password.MaxAgeReader < 0 {
+		errs = append(errs, fmt.Errorf("password.max_age_admin, password.max_age_agent and password.max_age_reader must not be negative"))
+	}
+
+	if c.Registration.InvitationTTL <= 0 {
+		errs = append(errs, fmt.Errorf("registration.invitation_ttl must be positive"))
+	}
+
+	oneOf("events.publisher", c.Events.Publisher, "redis", "log")
+	if c.Events.Publisher == "redis" {
+		required("events.stream", c.Events.Stream)
+		if c.Events.StreamMaxLen <= 0 {
+			errs = append(errs, fmt.Errorf("events.stream_max_len must be positive"))
+		}
+	}
+	if c.Events.Retention < 0 {
+		errs = append(errs, fmt.Errorf("events.retention must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (t *Transport) CallerTrusted() bool {
+	return t.TrustedGateway || (t.TLSCertFile != "" && t.TLSClientCAFile != "" && t.TLSClientAuth == "require")
+}
+
+func (c *Container) String() string {
+	var b strings.Builder
+	for _, f := range c.fields() {
+		fmt.Fprintf(&b, "%s: %s\n", f.key, f)
+	}
+	return b.String()
 }
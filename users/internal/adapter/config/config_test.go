@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestNew_Layers(t *testing.T) {
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("CONFIG_FILE", writeFile(t, "users.yaml", `
+db:
+  host: file-host
+  port: 6000
+  name: users
+  user: users
+transport:
+  port: 7000
+  shutdown_timeout: 3s
+`))
+	t.Setenv("DB_PORT", "6001")
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "password", "s3cret\n"))
+	t.Setenv("TRANSPORT_PORT", "7001")
+
+	cfg, args, err := New([]string{"-transport.port", "7002", "-db.auto_migrate=false", "status"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"status"}, args)
+	assert.Equal(t, "file-host", cfg.DB.Host, "File not applied")
+	assert.Equal(t, 6001, cfg.DB.Port, "Env does not override file")
+	assert.Equal(t, 7002, cfg.Transport.Port, "Flag does not override env")
+	assert.Equal(t, 3*time.Second, cfg.Transport.ShutdownTimeout)
+	assert.Equal(t, "s3cret", cfg.DB.Password, "Secret file not read")
+	assert.False(t, cfg.DB.AutoMigrate)
+	assert.Equal(t, 6379, cfg.Redis.Port, "Default lost")
+	assert.Equal(t, "prod", cfg.Transport.Env)
+}
+
+func TestNew_Validation(t *testing.T) {
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("DB_PORT", "not-a-port")
+	t.Setenv("TRANSPORT_SHUTDOWN_TIMEOUT", "soon")
+
+	_, _, err := New(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DB_PORT: invalid integer")
+	assert.Contains(t, err.Error(), "TRANSPORT_SHUTDOWN_TIMEOUT: invalid duration")
+
+	t.Setenv("DB_PORT", "")
+	t.Setenv("TRANSPORT_SHUTDOWN_TIMEOUT", "")
+	t.Setenv("REDIS_FAILURE_POLICY", "sometimes")
+	t.Setenv("PASSWORD_HISTORY_SIZE", "-1")
+	t.Setenv("REGISTRATION_INVITATION_TTL", "0s")
+	t.Setenv("TRANSPORT_IDEMPOTENCY_WINDOW", "-1h")
+	t.Setenv("EVENTS_PUBLISHER", "kafka")
+	t.Setenv("DB_SSLMODE", "prefer")
+	t.Setenv("CACHE_BACKEND", "disk")
+	t.Setenv("EVENTS_RETENTION", "-24h")
+
+	_, _, err = New(nil)
+	require.Error(t, err)
+	for _, want := range []string{"db.host is required", "db.name is required", "db.user is required", "redis.failure_policy", "password.history_size", "registration.invitation_ttl", "transport.idempotency_window", "events.publisher", "db.sslmode", "cache.backend", "events.retention"} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestNew_SecretAndFileBothSet(t *testing.T) {
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("REDIS_PASSWORD", "inline")
+	t.Setenv("REDIS_PASSWORD_FILE", writeFile(t, "password", "file"))
+
+	_, _, err := New(nil)
+	assert.ErrorContains(t, err, "REDIS_PASSWORD and REDIS_PASSWORD_FILE are both set")
+}
+
+func TestContainer_String(t *testing.T) {
+	cfg := defaults()
+	cfg.DB.Password = "s3cret"
+
+	printed := cfg.String()
+	assert.NotContains(t, printed, "s3cret")
+	assert.Contains(t, printed, "db.password: REDACTED\n")
+	assert.Contains(t, printed, "redis.password: \n")
+	assert.True(t, strings.Contains(printed, "transport.shutdown_timeout: 10s\n"))
+}
+
+func TestTransport_CallerTrusted(t *testing.T) {
+	mtls := Transport{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem", TLSClientCAFile: "ca.pem", TLSClientAuth: "require"}
+	optionalCerts := mtls
+	optionalCerts.TLSClientAuth = "verify_if_given"
+
+	tests := []struct {
+		name      string
+		transport Transport
+		want      bool
+	}{
+		{name: "Plaintext", transport: Transport{}, want: false},
+		{name: "ServerTLSOnly", transport: Transport{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"}, want: false},
+		{name: "OptionalClientCerts", transport: optionalCerts, want: false},
+		{name: "RequiredClientCerts", transport: mtls, want: true},
+		{name: "TrustedGateway", transport: Transport{TrustedGateway: true}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.transport.CallerTrusted())
+		})
+	}
+}
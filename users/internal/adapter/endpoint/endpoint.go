@@ -14,7 +14,25 @@ type Endpoints struct {
 	GetUserEndopoint    endpoint.Endpoint
 	ListUsersEndopoint  endpoint.Endpoint
 	UpdateUserEndopoint endpoint.Endpoint
-	DeleteEndopoint     endpoint.Endpoint
+	DeleteUserEndopoint endpoint.Endpoint
+	WatchUsersEndopoint endpoint.Endpoint
+
+	GetMeEndopoint          endpoint.Endpoint
+	UpdateMeEndopoint       endpoint.Endpoint
+	ChangePasswordEndopoint endpoint.Endpoint
+
+	VerifyCredentialsEndopoint endpoint.Endpoint
+	ListLoginEventsEndopoint   endpoint.Endpoint
+
+	InviteUserEndopoint       endpoint.Endpoint
+	AcceptInvitationEndopoint endpoint.Endpoint
+}
+
+// WatchUsersRequest carries the stream callback since go-kit endpoints are
+// request/response only.
+type WatchUsersRequest struct {
+	Since uint64
+	Send  func(*domain.UserEvent) error
 }
 
 func MakeServerEndpoints(us port.UserService) *Endpoints {
@@ -23,7 +41,18 @@ func MakeServerEndpoints(us port.UserService) *Endpoints {
 		GetUserEndopoint:    MakeGetUserEndopoint(us),
 		ListUsersEndopoint:  MakeListUsersEndopoint(us),
 		UpdateUserEndopoint: MakeUpdateUserEndopoint(us),
-		DeleteEndopoint:     MakeDeleteEndopoint(us),
+		DeleteUserEndopoint: MakeDeleteUserEndopoint(us),
+		WatchUsersEndopoint: MakeWatchUsersEndopoint(us),
+
+		GetMeEndopoint:          MakeGetMeEndopoint(us),
+		UpdateMeEndopoint:       MakeUpdateMeEndopoint(us),
+		ChangePasswordEndopoint: MakeChangePasswordEndopoint(us),
+
+		VerifyCredentialsEndopoint: MakeVerifyCredentialsEndopoint(us),
+		ListLoginEventsEndopoint:   MakeListLoginEventsEndopoint(us),
+
+		InviteUserEndopoint:       MakeInviteUserEndopoint(us),
+		AcceptInvitationEndopoint: MakeAcceptInvitationEndopoint(us),
 	}
 }
 
@@ -88,12 +117,15 @@ func MakeUpdateUserEndopoint(us port.UserService) endpoint.Endpoint {
 			return nil, err
 		}
 
+		// Unset fields are left empty, which the service reads as unchanged.
 		updateUser := &domain.User{
 			ID:       req.Id,
-			Name:     *req.Name,
-			Email:    *req.Email,
-			Password: *req.Password,
-			Role:     domain.Role(string(req.Role.String())),
+			Name:     req.GetName(),
+			Email:    req.GetEmail(),
+			Password: req.GetPassword(),
+		}
+		if req.GetRole() != usersv1.Role_ROLE_UNSPECIFIED {
+			updateUser.Role = domain.Role(req.GetRole().String())
 		}
 
 		user, err := us.UpdateUser(ctx, updateUser)
@@ -105,7 +137,7 @@ func MakeUpdateUserEndopoint(us port.UserService) endpoint.Endpoint {
 	}
 }
 
-func MakeDeleteEndopoint(us port.UserService) endpoint.Endpoint {
+func MakeDeleteUserEndopoint(us port.UserService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req, ok := request.(*usersv1.DeleteUserRequest)
 		if !ok {
@@ -115,3 +147,118 @@ func MakeDeleteEndopoint(us port.UserService) endpoint.Endpoint {
 		return nil, us.DeleteUser(ctx, req.Id)
 	}
 }
+
+func MakeWatchUsersEndopoint(us port.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*WatchUsersRequest)
+		if !ok {
+			return nil, err
+		}
+
+		return nil, us.WatchUsers(ctx, req.Since, req.Send)
+	}
+}
+
+func MakeGetMeEndopoint(us port.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_, ok := request.(*usersv1.GetMeRequest)
+		if !ok {
+			return nil, err
+		}
+
+		return us.GetMe(ctx)
+	}
+}
+
+func MakeUpdateMeEndopoint(us port.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*usersv1.UpdateMeRequest)
+		if !ok {
+			return nil, err
+		}
+
+		user, err := us.UpdateMe(ctx, &domain.User{Name: req.GetName(), Email: req.GetEmail()})
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}
+}
+
+func MakeChangePasswordEndopoint(us port.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*usersv1.ChangePasswordRequest)
+		if !ok {
+			return nil, err
+		}
+
+		return nil, us.ChangePassword(ctx, req.CurrentPassword, req.NewPassword)
+	}
+}
+
+func MakeVerifyCredentialsEndopoint(us port.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*usersv1.VerifyCredentialsRequest)
+		if !ok {
+			return nil, err
+		}
+
+		origin := domain.LoginOrigin{IPAddress: req.IpAddress, UserAgent: req.UserAgent}
+
+		user, err := us.VerifyCredentials(ctx, req.Email, req.Password, origin)
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}
+}
+
+func MakeListLoginEventsEndopoint(us port.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*usersv1.ListLoginEventsRequest)
+		if !ok {
+			return nil, err
+		}
+
+		events, err := us.ListLoginEvents(ctx, req.UserId, req.Skip, req.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		return events, nil
+	}
+}
+
+func MakeInviteUserEndopoint(us port.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*usersv1.InviteUserRequest)
+		if !ok {
+			return nil, err
+		}
+
+		invitation, err := us.InviteUser(ctx, req.Email, domain.Role(req.Role.String()))
+		if err != nil {
+			return nil, err
+		}
+
+		return invitation, nil
+	}
+}
+
+func MakeAcceptInvitationEndopoint(us port.UserService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*usersv1.AcceptInvitationRequest)
+		if !ok {
+			return nil, err
+		}
+
+		user, err := us.AcceptInvitation(ctx, req.Token, req.Name, req.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}
+}
@@ -0,0 +1,196 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var ErrorLimitExceeded = errors.New("too many concurrent requests")
+
+// Policy fields left zero are not enforced.
+type Policy struct {
+	Timeout       time.Duration
+	MaxConcurrent int
+}
+
+// Instruments left nil discard what they are given.
+type Instruments struct {
+	Requests metrics.Counter
+	Duration metrics.Histogram
+	InFlight metrics.Gauge
+}
+
+func NewPrometheusInstruments(registerer prometheus.Registerer) Instruments {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "users",
+		Subsystem: "endpoint",
+		Name:      "requests_total",
+		Help:      "Requests served by each endpoint.",
+	}, []string{"method", "success"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "users",
+		Subsystem: "endpoint",
+		Name:      "request_duration_seconds",
+		Help:      "Time taken serving each request.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "success"})
+	inFlight := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "users",
+		Subsystem: "endpoint",
+		Name:      "in_flight_requests",
+		Help:      "Requests being served by each endpoint.",
+	}, []string{"method"})
+
+	registerer.MustRegister(requests, duration, inFlight)
+
+	return Instruments{
+		Requests: kitprometheus.NewCounter(requests),
+		Duration: kitprometheus.NewHistogram(duration),
+		InFlight: kitprometheus.NewGauge(inFlight),
+	}
+}
+
+type Chain struct {
+	Logger      log.Logger
+	Instruments Instruments
+	Default     Policy
+	// Policies overrides Default by endpoint name, such as "WatchUsers".
+	Policies map[string]Policy
+}
+
+// Apply finds the endpoints by reflection, naming each after its field
+// without the Endopoint suffix.
+func (c Chain) Apply(e *Endpoints) *Endpoints {
+	wrapped := *e
+
+	v := reflect.ValueOf(&wrapped).Elem()
+	for i := range v.NumField() {
+		field := v.Field(i)
+		next, ok := field.Interface().(endpoint.Endpoint)
+		if !ok || next == nil {
+			continue
+		}
+
+		name := strings.TrimSuffix(v.Type().Field(i).Name, "Endopoint")
+		field.Set(reflect.ValueOf(c.wrap(name, next)))
+	}
+
+	return &wrapped
+}
+
+func (c Chain) wrap(name string, next endpoint.Endpoint) endpoint.Endpoint {
+	policy, ok := c.Policies[name]
+	if !ok {
+		policy = c.Default
+	}
+
+	logger := c.Logger
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	var inner []endpoint.Middleware
+	inner = append(inner, Recovering(name, logger))
+	if policy.MaxConcurrent > 0 {
+		inner = append(inner, ConcurrencyLimiting(policy.MaxConcurrent))
+	}
+	if policy.Timeout > 0 {
+		inner = append(inner, Timeout(policy.Timeout))
+	}
+
+	return endpoint.Chain(Instrumenting(name, c.Instruments), inner...)(next)
+}
+
+func Instrumenting(name string, instruments Instruments) endpoint.Middleware {
+	requests, duration, inFlight := instruments.Requests, instruments.Duration, instruments.InFlight
+	if requests == nil {
+		requests = discard.NewCounter()
+	}
+	if duration == nil {
+		duration = discard.NewHistogram()
+	}
+	if inFlight == nil {
+		inFlight = discard.NewGauge()
+	}
+	inFlight = inFlight.With("method", name)
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			inFlight.Add(1)
+			defer func(start time.Time) {
+				inFlight.Add(-1)
+
+				labels := []string{"method", name, "success", strconv.FormatBool(err == nil)}
+				requests.With(labels...).Add(1)
+				duration.With(labels...).Observe(time.Since(start).Seconds())
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// Recovering turns a panic into domain.ErrorInternal.
+func Recovering(name string, logger log.Logger) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					level.Error(utils.Logger(ctx, logger)).Log("msg", "endpoint panicked", "endpoint", name, "panic", r, "stack", string(debug.Stack()))
+					response, err = nil, domain.ErrorInternal
+				}
+			}()
+
+			return next(ctx, request)
+		}
+	}
+}
+
+func ConcurrencyLimiting(max int) endpoint.Middleware {
+	slots := make(chan struct{}, max)
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				return nil, ErrorLimitExceeded
+			}
+			defer func() { <-slots }()
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// Timeout reports timed out requests as context.DeadlineExceeded, whatever
+// error the layers below turned it into.
+func Timeout(timeout time.Duration) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			response, err := next(ctx, request)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, context.DeadlineExceeded
+			}
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package endpoint_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/endpoint"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func panicking(context.Context, interface{}) (interface{}, error) {
+	panic("boom")
+}
+
+// allEndpoints sets every endpoint of Endpoints to ep.
+func allEndpoints(ep kitendpoint.Endpoint) *endpoint.Endpoints {
+	e := &endpoint.Endpoints{}
+	v := reflect.ValueOf(e).Elem()
+	for i := range v.NumField() {
+		v.Field(i).Set(reflect.ValueOf(ep))
+	}
+	return e
+}
+
+func TestChain_AppliesToEveryEndpoint(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	chain := endpoint.Chain{
+		Logger:      log.NewNopLogger(),
+		Instruments: endpoint.NewPrometheusInstruments(registry),
+	}
+
+	wrapped := chain.Apply(allEndpoints(panicking))
+
+	v := reflect.ValueOf(wrapped).Elem()
+	for i := range v.NumField() {
+		name := v.Type().Field(i).Name
+		ep := v.Field(i).Interface().(kitendpoint.Endpoint)
+
+		_, err := ep(context.Background(), nil)
+		assert.Equal(t, domain.ErrorInternal, err, "%s not recovered", name)
+	}
+
+	count, err := testutil.GatherAndCount(registry, "users_endpoint_requests_total")
+	require.NoError(t, err)
+	assert.Equal(t, v.NumField(), count, "Not every endpoint instrumented")
+}
+
+func TestChain_Policies(t *testing.T) {
+	slow := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			return nil, domain.ErrorInternal
+		case <-time.After(time.Second):
+			return "done", nil
+		}
+	}
+
+	chain := endpoint.Chain{
+		Default:  endpoint.Policy{Timeout: 10 * time.Millisecond},
+		Policies: map[string]endpoint.Policy{"WatchUsers": {}},
+	}
+	wrapped := chain.Apply(&endpoint.Endpoints{GetUserEndopoint: slow, WatchUsersEndopoint: slow})
+
+	_, err := wrapped.GetUserEndopoint(context.Background(), nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Default timeout not applied")
+
+	response, err := wrapped.WatchUsersEndopoint(context.Background(), nil)
+	assert.NoError(t, err, "Policy did not replace the default")
+	assert.Equal(t, "done", response)
+}
+
+func TestInstrumenting(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	instruments := endpoint.NewPrometheusInstruments(registry)
+
+	ep := endpoint.Instrumenting("GetUser", instruments)(func(_ context.Context, request interface{}) (interface{}, error) {
+		if request == nil {
+			return nil, errors.New("failed")
+		}
+		return request, nil
+	})
+
+	_, _ = ep(context.Background(), "ok")
+	_, _ = ep(context.Background(), "ok")
+	_, _ = ep(context.Background(), nil)
+
+	expected := `
+# HELP users_endpoint_requests_total Requests served by each endpoint.
+# TYPE users_endpoint_requests_total counter
+users_endpoint_requests_total{method="GetUser",success="false"} 1
+users_endpoint_requests_total{method="GetUser",success="true"} 2
+# HELP users_endpoint_in_flight_requests Requests being served by each endpoint.
+# TYPE users_endpoint_in_flight_requests gauge
+users_endpoint_in_flight_requests{method="GetUser"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"users_endpoint_requests_total", "users_endpoint_in_flight_requests"))
+}
+
+func TestConcurrencyLimiting(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+
+	ep := endpoint.ConcurrencyLimiting(2)(func(context.Context, interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	})
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ep(context.Background(), nil)
+			assert.NoError(t, err)
+		}()
+	}
+	<-started
+	<-started
+
+	_, err := ep(context.Background(), nil)
+	assert.ErrorIs(t, err, endpoint.ErrorLimitExceeded)
+
+	close(release)
+	wg.Wait()
+
+	_, err = ep(context.Background(), nil)
+	assert.NoError(t, err, "Slots not released")
+}
+
+func TestTimeout(t *testing.T) {
+	ep := endpoint.Timeout(time.Second)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		_, ok := ctx.Deadline()
+		return ok, nil
+	})
+
+	hasDeadline, err := ep(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, true, hasDeadline)
+}
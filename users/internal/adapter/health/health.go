@@ -0,0 +1,176 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Check failures only stop the service when Critical.
+type Check struct {
+	Name     string
+	Critical bool
+	Probe    func(ctx context.Context) error
+}
+
+type Checker struct {
+	server   *health.Server
+	services []string
+	checks   []Check
+	timeout  time.Duration
+	logger   log.Logger
+
+	mu       sync.RWMutex
+	serving  bool
+	draining bool
+	failures map[string]string
+}
+
+func New(logger log.Logger, timeout time.Duration, services []string, checks ...Check) *Checker {
+	c := &Checker{
+		server:   health.NewServer(),
+		services: append([]string{""}, services...),
+		checks:   checks,
+		timeout:  timeout,
+		logger:   logger,
+		failures: make(map[string]string),
+	}
+	c.publish()
+	return c
+}
+
+func (c *Checker) Server() healthpb.HealthServer {
+	return c.server
+}
+
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.CheckNow(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Checker) CheckNow(ctx context.Context) {
+	failures := make(map[string]string)
+	serving := true
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			err := check.Probe(ctx)
+			if err == nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			failures[check.Name] = err.Error()
+			if check.Critical {
+				serving = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	for name, reason := range failures {
+		if _, failing := c.failures[name]; !failing {
+			level.Warn(c.logger).Log("msg", "health check failing", "check", name, "err", reason)
+		}
+	}
+	for name := range c.failures {
+		if _, failing := failures[name]; !failing {
+			level.Info(c.logger).Log("msg", "health check recovered", "check", name)
+		}
+	}
+	c.failures, c.serving = failures, serving
+	c.mu.Unlock()
+
+	c.publish()
+}
+
+func (c *Checker) Drain() {
+	c.mu.Lock()
+	c.draining = true
+	c.mu.Unlock()
+
+	c.server.Shutdown()
+}
+
+func (c *Checker) publish() {
+	c.mu.RLock()
+	draining, status := c.draining, c.status()
+	c.mu.RUnlock()
+
+	if draining {
+		return
+	}
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, status)
+	}
+}
+
+func (c *Checker) status() healthpb.HealthCheckResponse_ServingStatus {
+	if c.serving && !c.draining {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+// Handler serves /healthz while the process is up and /readyz while it is
+// SERVING.
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		c.write(w, http.StatusOK)
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		code := http.StatusOK
+
+		c.mu.RLock()
+		if c.status() != healthpb.HealthCheckResponse_SERVING {
+			code = http.StatusServiceUnavailable
+		}
+		c.mu.RUnlock()
+
+		c.write(w, code)
+	})
+	return mux
+}
+
+type report struct {
+	Status   string            `json:"status"`
+	Failures map[string]string `json:"failures,omitempty"`
+}
+
+func (c *Checker) write(w http.ResponseWriter, code int) {
+	c.mu.RLock()
+	body := report{Status: c.status().String(), Failures: c.failures}
+	c.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
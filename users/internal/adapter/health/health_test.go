@@ -0,0 +1,188 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/health"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const service = "users.v1.UserService"
+
+// probe fails while its error is set.
+type probe struct {
+	err atomic.Pointer[error]
+}
+
+func (p *probe) fail(err error) { p.err.Store(&err) }
+
+func (p *probe) recover() { p.err.Store(nil) }
+
+func (p *probe) Probe(ctx context.Context) error {
+	if err := p.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func status(t *testing.T, checker *health.Checker, name string) healthpb.HealthCheckResponse_ServingStatus {
+	res, err := checker.Server().Check(context.Background(), &healthpb.HealthCheckRequest{Service: name})
+	require.NoError(t, err)
+	return res.Status
+}
+
+func get(t *testing.T, checker *health.Checker, path string) (int, map[string]any) {
+	rec := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body
+}
+
+func TestChecker_Status(t *testing.T) {
+	tests := []struct {
+		name        string
+		database    error
+		redis       error
+		wantStatus  healthpb.HealthCheckResponse_ServingStatus
+		wantReadyz  int
+		wantFailing []string
+	}{
+		{
+			name:       "Success_AllHealthy",
+			wantStatus: healthpb.HealthCheckResponse_SERVING,
+			wantReadyz: http.StatusOK,
+		},
+		{
+			name:        "Error_CriticalFailing",
+			database:    errors.New("connection refused"),
+			wantStatus:  healthpb.HealthCheckResponse_NOT_SERVING,
+			wantReadyz:  http.StatusServiceUnavailable,
+			wantFailing: []string{"database"},
+		},
+		{
+			name:        "Success_NonCriticalFailing",
+			redis:       errors.New("connection refused"),
+			wantStatus:  healthpb.HealthCheckResponse_SERVING,
+			wantReadyz:  http.StatusOK,
+			wantFailing: []string{"redis"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database, redis := &probe{}, &probe{}
+			if tt.database != nil {
+				database.fail(tt.database)
+			}
+			if tt.redis != nil {
+				redis.fail(tt.redis)
+			}
+
+			checker := health.New(log.NewNopLogger(), time.Second, []string{service},
+				health.Check{Name: "database", Critical: true, Probe: database.Probe},
+				health.Check{Name: "redis", Probe: redis.Probe},
+			)
+			checker.CheckNow(context.Background())
+
+			assert.Equal(t, tt.wantStatus, status(t, checker, ""))
+			assert.Equal(t, tt.wantStatus, status(t, checker, service))
+
+			code, body := get(t, checker, "/readyz")
+			assert.Equal(t, tt.wantReadyz, code)
+			assert.Equal(t, tt.wantStatus.String(), body["status"])
+
+			failures, _ := body["failures"].(map[string]any)
+			assert.Len(t, failures, len(tt.wantFailing))
+			for _, name := range tt.wantFailing {
+				assert.Contains(t, failures, name)
+			}
+
+			code, _ = get(t, checker, "/healthz")
+			assert.Equal(t, http.StatusOK, code)
+		})
+	}
+}
+
+func TestChecker_Transitions(t *testing.T) {
+	database := &probe{}
+	checker := health.New(log.NewNopLogger(), time.Second, []string{service},
+		health.Check{Name: "database", Critical: true, Probe: database.Probe},
+	)
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, checker, ""), "Serving before the first check")
+
+	checker.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, checker, ""))
+
+	database.fail(errors.New("connection refused"))
+	checker.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, checker, service))
+
+	database.recover()
+	checker.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, checker, service))
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := health.New(log.NewNopLogger(), 10*time.Millisecond, nil,
+		health.Check{Name: "database", Critical: true, Probe: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+	checker.CheckNow(context.Background())
+
+	code, body := get(t, checker, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]any{"database": context.DeadlineExceeded.Error()}, body["failures"])
+}
+
+func TestChecker_Drain(t *testing.T) {
+	checker := health.New(log.NewNopLogger(), time.Second, []string{service})
+	checker.CheckNow(context.Background())
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, checker, service))
+
+	checker.Drain()
+	checker.CheckNow(context.Background())
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, checker, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, checker, service), "Serving again after drain")
+
+	code, _ := get(t, checker, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	code, _ = get(t, checker, "/healthz")
+	assert.Equal(t, http.StatusOK, code, "Liveness must survive drain")
+}
+
+func TestChecker_Run(t *testing.T) {
+	database := &probe{}
+	checker := health.New(log.NewNopLogger(), time.Second, nil,
+		health.Check{Name: "database", Critical: true, Probe: database.Probe},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go checker.Run(ctx, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return status(t, checker, "") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 5*time.Millisecond)
+
+	database.fail(errors.New("connection refused"))
+	assert.Eventually(t, func() bool {
+		return status(t, checker, "") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 5*time.Millisecond)
+}
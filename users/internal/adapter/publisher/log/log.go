@@ -0,0 +1,21 @@
+package log
+
+import (
+	"context"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+type Publisher struct {
+	logger log.Logger
+}
+
+func New(logger log.Logger) *Publisher {
+	return &Publisher{logger: logger}
+}
+
+func (p *Publisher) Publish(_ context.Context, event domain.DomainEvent) error {
+	return level.Info(p.logger).Log("msg", "user event", "id", event.ID, "type", event.Type, "user_id", event.UserID, "payload", string(event.Payload))
+}
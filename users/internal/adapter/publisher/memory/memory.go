@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+)
+
+type Publisher struct {
+	mu     sync.Mutex
+	events []domain.DomainEvent
+	err    error
+}
+
+func New() *Publisher {
+	return &Publisher{}
+}
+
+func (p *Publisher) Publish(_ context.Context, event domain.DomainEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *Publisher) Events() []domain.DomainEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]domain.DomainEvent(nil), p.events...)
+}
+
+// Fail makes Publish return err until it is called with nil.
+func (p *Publisher) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
@@ -0,0 +1,237 @@
+package failopen
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/sony/gobreaker"
+)
+
+const (
+	tripAfterFailures = 5
+	openTimeout       = 10 * time.Second
+)
+
+// Cache turns failures into misses and dropped writes. Failed invalidations
+// are replayed before this Cache reads again. They are kept in memory only:
+// other replicas sharing the cache are not told, and a restart loses them,
+// so entries they cover may be served elsewhere until their TTL runs out.
+type Cache struct {
+	cache   port.CacheRepository
+	breaker *gobreaker.CircuitBreaker
+	logger  log.Logger
+
+	mu                sync.Mutex
+	pendingKeys       map[string]struct{}
+	pendingPrefixes   map[string]struct{}
+	pendingIncrements map[string]struct{}
+}
+
+func New(cache port.CacheRepository, logger log.Logger) port.CacheRepository {
+	c := &Cache{
+		cache:             cache,
+		logger:            logger,
+		pendingKeys:       make(map[string]struct{}),
+		pendingPrefixes:   make(map[string]struct{}),
+		pendingIncrements: make(map[string]struct{}),
+	}
+
+	c.breaker = gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:    "cache",
+		Timeout: openTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= tripAfterFailures
+		},
+		IsSuccessful: func(err error) bool {
+			return err == nil || errors.Is(err, domain.ErrorDataNotFound)
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			level.Warn(logger).Log("msg", "cache circuit breaker state changed", "from", from, "to", to)
+		},
+	})
+
+	return c
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := c.execute(func() error {
+		return c.cache.Set(ctx, key, value, ttl)
+	})
+	if err != nil {
+		c.logFailure(ctx, "set", key, err)
+	}
+
+	return nil
+}
+
+// SetIfAbsent returns the failure, since a guessed answer would let duplicates
+// through or turn every caller away.
+func (c *Cache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := c.execute(func() error {
+		var err error
+		ok, err = c.cache.SetIfAbsent(ctx, key, value, ttl)
+		return err
+	})
+	if err != nil {
+		c.logFailure(ctx, "set_if_absent", key, err)
+		return false, err
+	}
+
+	return ok, nil
+}
+
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := c.flush(ctx); err != nil {
+		return nil, domain.ErrorDataNotFound
+	}
+
+	var value []byte
+	err := c.execute(func() error {
+		var err error
+		value, err = c.cache.Get(ctx, key)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrorDataNotFound) {
+			c.logFailure(ctx, "get", key, err)
+		}
+		return nil, domain.ErrorDataNotFound
+	}
+
+	return value, nil
+}
+
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	err := c.execute(func() error {
+		return c.cache.Delete(ctx, key)
+	})
+	if err != nil {
+		c.logFailure(ctx, "delete", key, err)
+
+		c.mu.Lock()
+		c.pendingKeys[key] = struct{}{}
+		c.mu.Unlock()
+	}
+
+	return nil
+}
+
+func (c *Cache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	err := c.execute(func() error {
+		return c.cache.DeleteByPrefix(ctx, prefix)
+	})
+	if err != nil {
+		c.logFailure(ctx, "delete_by_prefix", prefix, err)
+
+		c.mu.Lock()
+		c.pendingPrefixes[prefix] = struct{}{}
+		c.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Increment replays a failed bump once, which is enough to orphan everything
+// cached under the old value.
+func (c *Cache) Increment(ctx context.Context, key string) (int64, error) {
+	var value int64
+	err := c.execute(func() error {
+		var err error
+		value, err = c.cache.Increment(ctx, key)
+		return err
+	})
+	if err != nil {
+		c.logFailure(ctx, "increment", key, err)
+
+		c.mu.Lock()
+		c.pendingIncrements[key] = struct{}{}
+		c.mu.Unlock()
+	}
+
+	return value, nil
+}
+
+func (c *Cache) Close() error {
+	return c.cache.Close()
+}
+
+func (c *Cache) flush(ctx context.Context) error {
+	c.mu.Lock()
+	if len(c.pendingKeys) == 0 && len(c.pendingPrefixes) == 0 && len(c.pendingIncrements) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	keys := slices.Collect(maps.Keys(c.pendingKeys))
+	prefixes := slices.Collect(maps.Keys(c.pendingPrefixes))
+	increments := slices.Collect(maps.Keys(c.pendingIncrements))
+	c.mu.Unlock()
+
+	for _, key := range increments {
+		err := c.execute(func() error {
+			_, err := c.cache.Increment(ctx, key)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		delete(c.pendingIncrements, key)
+		c.mu.Unlock()
+	}
+
+	for _, key := range keys {
+		err := c.execute(func() error {
+			return c.cache.Delete(ctx, key)
+		})
+		if err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		delete(c.pendingKeys, key)
+		c.mu.Unlock()
+	}
+
+	for _, prefix := range prefixes {
+		err := c.execute(func() error {
+			return c.cache.DeleteByPrefix(ctx, prefix)
+		})
+		if err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		delete(c.pendingPrefixes, prefix)
+		c.mu.Unlock()
+	}
+
+	level.Info(c.logger).Log("msg", "replayed pending cache invalidations", "keys", len(keys), "prefixes", len(prefixes), "increments", len(increments))
+
+	return nil
+}
+
+func (c *Cache) execute(fn func() error) error {
+	_, err := c.breaker.Execute(func() (interface{}, error) {
+		return nil, fn()
+	})
+	return err
+}
+
+func (c *Cache) logFailure(ctx context.Context, op, key string, err error) {
+	// The state change already reported the breaker opening.
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return
+	}
+	level.Warn(utils.Logger(ctx, c.logger)).Log("msg", "cache operation failed", "op", op, "key", key, "err", err)
+}
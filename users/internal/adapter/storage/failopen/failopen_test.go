@@ -0,0 +1,104 @@
+package failopen_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/failopen"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port/mocks"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var errUnavailable = errors.New("connection refused")
+
+func TestCache_BypassesFailures(t *testing.T) {
+	ctx := context.Background()
+	inner := mocks.NewCacheRepository(t)
+	cache := failopen.New(inner, log.NewNopLogger())
+
+	inner.On("Set", ctx, "user:1", []byte("one"), time.Minute).Return(errUnavailable).Once()
+	inner.On("Get", ctx, "user:1").Return(nil, errUnavailable).Once()
+
+	assert.NoError(t, cache.Set(ctx, "user:1", []byte("one"), time.Minute))
+
+	_, err := cache.Get(ctx, "user:1")
+	assert.Equal(t, domain.ErrorDataNotFound, err, "Failure not reported as a miss")
+}
+
+func TestCache_SetIfAbsentReportsFailures(t *testing.T) {
+	ctx := context.Background()
+	inner := mocks.NewCacheRepository(t)
+	cache := failopen.New(inner, log.NewNopLogger())
+
+	inner.On("SetIfAbsent", ctx, "idempotency:1", []byte("one"), time.Minute).Return(false, errUnavailable).Once()
+
+	set, err := cache.SetIfAbsent(ctx, "idempotency:1", []byte("one"), time.Minute)
+	assert.Equal(t, errUnavailable, err, "Failure hidden")
+	assert.False(t, set, "Key reported as set")
+}
+
+func TestCache_OpensBreaker(t *testing.T) {
+	ctx := context.Background()
+	inner := mocks.NewCacheRepository(t)
+	cache := failopen.New(inner, log.NewNopLogger())
+
+	inner.On("Get", ctx, "user:1").Return(nil, errUnavailable).Times(5)
+
+	for i := 0; i < 10; i++ {
+		_, err := cache.Get(ctx, "user:1")
+		assert.Equal(t, domain.ErrorDataNotFound, err)
+	}
+
+	inner.AssertNumberOfCalls(t, "Get", 5)
+}
+
+func TestCache_MissesDoNotOpenBreaker(t *testing.T) {
+	ctx := context.Background()
+	inner := mocks.NewCacheRepository(t)
+	cache := failopen.New(inner, log.NewNopLogger())
+
+	inner.On("Get", ctx, "user:1").Return(nil, domain.ErrorDataNotFound).Times(10)
+
+	for i := 0; i < 10; i++ {
+		_, err := cache.Get(ctx, "user:1")
+		assert.Equal(t, domain.ErrorDataNotFound, err)
+	}
+}
+
+func TestCache_ReplaysPendingInvalidations(t *testing.T) {
+	ctx := context.Background()
+	inner := mocks.NewCacheRepository(t)
+	cache := failopen.New(inner, log.NewNopLogger())
+
+	inner.On("Delete", ctx, "user:1").Return(errUnavailable).Once()
+	inner.On("DeleteByPrefix", ctx, "users:*").Return(errUnavailable).Once()
+
+	assert.NoError(t, cache.Delete(ctx, "user:1"))
+	assert.NoError(t, cache.DeleteByPrefix(ctx, "users:*"))
+
+	// Still down: the stale entry must not be read.
+	inner.On("Delete", ctx, "user:1").Return(errUnavailable).Once()
+
+	_, err := cache.Get(ctx, "user:1")
+	assert.Equal(t, domain.ErrorDataNotFound, err)
+	inner.AssertNotCalled(t, "Get", ctx, "user:1")
+
+	// Recovered: invalidations are applied before the read.
+	inner.On("Delete", ctx, "user:1").Return(nil).Once()
+	inner.On("DeleteByPrefix", ctx, "users:*").Return(nil).Once()
+	inner.On("Get", ctx, "user:1").Return(nil, domain.ErrorDataNotFound).Once()
+
+	_, err = cache.Get(ctx, "user:1")
+	assert.Equal(t, domain.ErrorDataNotFound, err)
+
+	// Nothing left to replay.
+	inner.On("Get", ctx, "user:1").Return([]byte("one"), nil).Once()
+
+	value, err := cache.Get(ctx, "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("one"), value)
+}
@@ -0,0 +1,113 @@
+package invalidation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Cache keeps a replica-local cache coherent with the other replicas.
+type Cache struct {
+	local  port.CacheRepository
+	bus    port.InvalidationBus
+	logger log.Logger
+	origin string
+}
+
+func New(ctx context.Context, local port.CacheRepository, bus port.InvalidationBus, logger log.Logger) (port.CacheRepository, error) {
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return nil, err
+	}
+
+	c := &Cache{
+		local:  local,
+		bus:    bus,
+		logger: logger,
+		origin: hex.EncodeToString(origin),
+	}
+
+	err := bus.Subscribe(ctx, c.apply)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.local.Set(ctx, key, value, ttl)
+}
+
+func (c *Cache) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return c.local.SetIfAbsent(ctx, key, value, ttl)
+}
+
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.local.Get(ctx, key)
+}
+
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	err := c.local.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return c.publish(ctx, domain.InvalidateKey, key)
+}
+
+func (c *Cache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	err := c.local.DeleteByPrefix(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	return c.publish(ctx, domain.InvalidatePrefix, prefix)
+}
+
+func (c *Cache) Increment(ctx context.Context, key string) (int64, error) {
+	value, err := c.local.Increment(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, c.publish(ctx, domain.InvalidateGeneration, key)
+}
+
+func (c *Cache) Close() error {
+	return errors.Join(c.bus.Close(), c.local.Close())
+}
+
+func (c *Cache) publish(ctx context.Context, kind domain.InvalidationKind, key string) error {
+	return c.bus.Publish(ctx, domain.Invalidation{Kind: kind, Key: key, Origin: c.origin})
+}
+
+// apply bypasses c so remote invalidations are not published again.
+func (c *Cache) apply(invalidation domain.Invalidation) {
+	if invalidation.Origin == c.origin {
+		return
+	}
+
+	ctx := context.Background()
+
+	var err error
+	switch invalidation.Kind {
+	case domain.InvalidateKey:
+		err = c.local.Delete(ctx, invalidation.Key)
+	case domain.InvalidatePrefix:
+		err = c.local.DeleteByPrefix(ctx, invalidation.Key)
+	case domain.InvalidateGeneration:
+		_, err = c.local.Increment(ctx, invalidation.Key)
+	}
+
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to apply remote cache invalidation", "kind", invalidation.Kind, "key", invalidation.Key, "err", err)
+	}
+}
@@ -0,0 +1,71 @@
+package invalidation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/invalidation"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/memory"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplica(t *testing.T, ctx context.Context, bus port.InvalidationBus) port.CacheRepository {
+	cache, err := invalidation.New(ctx, memory.New(0), bus, log.NewNopLogger())
+	require.NoError(t, err)
+	return cache
+}
+
+func TestCache_PropagatesInvalidations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := memory.NewInvalidationBus()
+	a := newReplica(t, ctx, bus)
+	b := newReplica(t, ctx, bus)
+
+	for _, replica := range []port.CacheRepository{a, b} {
+		_ = replica.Set(ctx, "user:1", []byte("one"), 0)
+		_ = replica.Set(ctx, "users:0:1:10", []byte("list"), 0)
+	}
+
+	assert.NoError(t, a.Delete(ctx, "user:1"))
+	_, err := b.Get(ctx, "user:1")
+	assert.Equal(t, domain.ErrorDataNotFound, err, "Key invalidation not applied")
+
+	assert.NoError(t, a.DeleteByPrefix(ctx, "users:*"))
+	_, err = b.Get(ctx, "users:0:1:10")
+	assert.Equal(t, domain.ErrorDataNotFound, err, "Prefix invalidation not applied")
+
+	_, err = a.Increment(ctx, "users:generation")
+	assert.NoError(t, err)
+	generation, err := b.Get(ctx, "users:generation")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), generation, "Generation bump not applied")
+
+	// The publisher applied the bump itself and must not apply it twice.
+	generation, err = a.Get(ctx, "users:generation")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), generation, "Own invalidation applied again")
+}
+
+func TestCache_StopsOnCancel(t *testing.T) {
+	ctx := context.Background()
+	subscribed, cancel := context.WithCancel(ctx)
+
+	bus := memory.NewInvalidationBus()
+	a := newReplica(t, ctx, bus)
+	b := newReplica(t, subscribed, bus)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_ = b.Set(ctx, "user:1", []byte("one"), 0)
+		_ = a.Delete(ctx, "user:1")
+		_, err := b.Get(ctx, "user:1")
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "Invalidations still applied after cancel")
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+)
+
+// InvalidationBus delivers synchronously.
+type InvalidationBus struct {
+	mu          sync.Mutex
+	next        int
+	subscribers map[int]func(domain.Invalidation)
+}
+
+func NewInvalidationBus() *InvalidationBus {
+	return &InvalidationBus{subscribers: make(map[int]func(domain.Invalidation))}
+}
+
+func (b *InvalidationBus) Publish(_ context.Context, invalidation domain.Invalidation) error {
+	b.mu.Lock()
+	handlers := make([]func(domain.Invalidation), 0, len(b.subscribers))
+	for _, handler := range b.subscribers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(invalidation)
+	}
+
+	return nil
+}
+
+func (b *InvalidationBus) Subscribe(ctx context.Context, handler func(domain.Invalidation)) error {
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.subscribers[id] = handler
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		delete(b.subscribers, id)
+		b.mu.Unlock()
+	}()
+
+	return nil
+}
+
+func (b *InvalidationBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = make(map[int]func(domain.Invalidation))
+	return nil
+}
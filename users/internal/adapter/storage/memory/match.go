@@ -0,0 +1,94 @@
+package memory
+
+// matchPattern follows Redis' stringmatchlen.
+func matchPattern(pattern, str string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(str); i++ {
+				if matchPattern(pattern[1:], str[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+			str = str[1:]
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+			var ok bool
+			ok, pattern = matchClass(pattern[1:], str[0])
+			if !ok {
+				return false
+			}
+			str = str[1:]
+			if len(pattern) == 0 {
+				// Unterminated class: it consumed the rest of the pattern.
+				return len(str) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(str) == 0 || pattern[0] != str[0] {
+				return false
+			}
+			str = str[1:]
+		}
+		pattern = pattern[1:]
+	}
+
+	return len(str) == 0
+}
+
+// matchClass returns the pattern at the closing ']', or empty if there is
+// none.
+func matchClass(pattern string, c byte) (bool, string) {
+	not := len(pattern) > 0 && pattern[0] == '^'
+	if not {
+		pattern = pattern[1:]
+	}
+
+	match := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			pattern = pattern[1:]
+			if pattern[0] == c {
+				match = true
+			}
+		case len(pattern) >= 3 && pattern[1] == '-':
+			start, end := pattern[0], pattern[2]
+			if start > end {
+				start, end = end, start
+			}
+			if c >= start && c <= end {
+				match = true
+			}
+			pattern = pattern[2:]
+		default:
+			if pattern[0] == c {
+				match = true
+			}
+		}
+		pattern = pattern[1:]
+	}
+
+	if not {
+		match = !match
+	}
+
+	return match, pattern
+}
@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+)
+
+// Memory drops expired entries lazily, when read or evicted.
+type Memory struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// New evicts the least recently used entries past size, unless it is zero.
+func New(size int) port.CacheRepository {
+	return newMemory(size)
+}
+
+func newMemory(size int) *Memory {
+	return &Memory{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(key, value, ttl)
+	return nil
+}
+
+func (m *Memory) SetIfAbsent(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok && !m.expired(el.Value.(*entry)) {
+		return false, nil
+	}
+
+	m.set(key, value, ttl)
+	return true, nil
+}
+
+func (m *Memory) set(key string, value []byte, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = m.now().Add(ttl)
+	}
+
+	value = append([]byte(nil), value...)
+
+	if el, ok := m.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		m.lru.MoveToFront(el)
+		return
+	}
+
+	m.entries[key] = m.lru.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+
+	m.evict()
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, domain.ErrorDataNotFound
+	}
+
+	e := el.Value.(*entry)
+	if m.expired(e) {
+		m.remove(el)
+		return nil, domain.ErrorDataNotFound
+	}
+
+	m.lru.MoveToFront(el)
+	return append([]byte(nil), e.value...), nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+
+	return nil
+}
+
+// DeleteByPrefix matches like Redis SCAN MATCH.
+func (m *Memory) DeleteByPrefix(_ context.Context, prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, el := range m.entries {
+		if matchPattern(prefix, key) {
+			m.remove(el)
+		}
+	}
+
+	return nil
+}
+
+// Increment keeps the expiry like Redis INCR.
+func (m *Memory) Increment(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var current int64
+	var expiresAt time.Time
+
+	if el, ok := m.entries[key]; ok {
+		e := el.Value.(*entry)
+		if !m.expired(e) {
+			value, err := strconv.ParseInt(string(e.value), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			current = value
+			expiresAt = e.expiresAt
+		}
+		m.remove(el)
+	}
+
+	current++
+	m.entries[key] = m.lru.PushFront(&entry{key: key, value: []byte(strconv.FormatInt(current, 10)), expiresAt: expiresAt})
+
+	m.evict()
+
+	return current, nil
+}
+
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]*list.Element)
+	m.lru.Init()
+	return nil
+}
+
+func (m *Memory) expired(e *entry) bool {
+	return !e.expiresAt.IsZero() && !m.now().Before(e.expiresAt)
+}
+
+func (m *Memory) evict() {
+	for m.size > 0 && m.lru.Len() > m.size {
+		m.remove(m.lru.Back())
+	}
+}
+
+func (m *Memory) remove(el *list.Element) {
+	m.lru.Remove(el)
+	delete(m.entries, el.Value.(*entry).key)
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	cache := newMemory(0)
+	cache.now = func() time.Time { return now }
+
+	_ = cache.Set(ctx, "user:1", []byte("one"), time.Minute)
+	_ = cache.Set(ctx, "user:2", []byte("two"), 0)
+
+	value, err := cache.Get(ctx, "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("one"), value)
+
+	now = now.Add(time.Minute)
+
+	_, err = cache.Get(ctx, "user:1")
+	assert.Equal(t, domain.ErrorDataNotFound, err, "Expired entry served")
+
+	value, err = cache.Get(ctx, "user:2")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("two"), value, "Entry without ttl expired")
+}
+
+func TestMemory_LRU(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemory(2)
+
+	_ = cache.Set(ctx, "a", []byte("a"), 0)
+	_ = cache.Set(ctx, "b", []byte("b"), 0)
+	_, _ = cache.Get(ctx, "a")
+	_ = cache.Set(ctx, "c", []byte("c"), 0)
+
+	_, err := cache.Get(ctx, "b")
+	assert.Equal(t, domain.ErrorDataNotFound, err, "Least recently used entry kept")
+
+	for _, key := range []string{"a", "c"} {
+		_, err := cache.Get(ctx, key)
+		assert.NoError(t, err, key)
+	}
+}
+
+func TestMemory_DeleteByPrefix(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemory(0)
+
+	for _, key := range []string{"users:1:10", "users:2:10", "user:1", "usersx"} {
+		_ = cache.Set(ctx, key, []byte(key), 0)
+	}
+
+	_ = cache.DeleteByPrefix(ctx, "users:*")
+
+	for key, kept := range map[string]bool{"users:1:10": false, "users:2:10": false, "user:1": true, "usersx": true} {
+		_, err := cache.Get(ctx, key)
+		assert.Equal(t, kept, err == nil, key)
+	}
+}
+
+func TestMemory_Increment(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemory(0)
+
+	for want := int64(1); want <= 3; want++ {
+		value, err := cache.Increment(ctx, "users:generation")
+		assert.NoError(t, err)
+		assert.Equal(t, want, value)
+	}
+
+	value, err := cache.Get(ctx, "users:generation")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("3"), value)
+
+	_ = cache.Set(ctx, "user:1", []byte("one"), 0)
+	_, err = cache.Increment(ctx, "user:1")
+	assert.Error(t, err, "Non-integer value incremented")
+}
+
+func TestMemory_SetIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemory(0)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	ok, err := cache.SetIfAbsent(ctx, "key", []byte("first"), time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = cache.SetIfAbsent(ctx, "key", []byte("second"), time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok, "Existing key overwritten")
+
+	value, _ := cache.Get(ctx, "key")
+	assert.Equal(t, []byte("first"), value)
+
+	now = now.Add(time.Minute)
+	ok, err = cache.SetIfAbsent(ctx, "key", []byte("third"), time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok, "Expired key not claimed")
+}
+
+func TestMatchPattern(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		str     string
+		match   bool
+	}{
+		{"users:*", "users:1:10", true},
+		{"users:*", "user:1", false},
+		{"*", "", true},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"h[b-a]llo", "hallo", true},
+		{"h[a-b]llo", "hcllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"[abc", "a", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+"/"+tc.str, func(t *testing.T) {
+			assert.Equal(t, tc.match, matchPattern(tc.pattern, tc.str))
+		})
+	}
+}
+
+func TestTiered(t *testing.T) {
+	ctx := context.Background()
+	local := newMemory(0)
+	remote := newMemory(0)
+
+	cache := NewTiered(local, remote, time.Minute, "user:")
+
+	_ = cache.Set(ctx, "user:1", []byte("one"), 0)
+	_ = cache.Set(ctx, "users:1:10", []byte("list"), 0)
+
+	_, err := local.Get(ctx, "user:1")
+	assert.NoError(t, err, "Hot key not kept locally")
+	_, err = local.Get(ctx, "users:1:10")
+	assert.Equal(t, domain.ErrorDataNotFound, err, "Cold key kept locally")
+
+	_ = local.Delete(ctx, "user:1")
+	value, err := cache.Get(ctx, "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("one"), value)
+	_, err = local.Get(ctx, "user:1")
+	assert.NoError(t, err, "Hot key not refilled from remote")
+
+	now := time.Now()
+	local.now = func() time.Time { return now.Add(time.Minute) }
+	_, err = local.Get(ctx, "user:1")
+	assert.Equal(t, domain.ErrorDataNotFound, err, "Refilled key outlived the local TTL")
+	local.now = time.Now
+
+	_ = cache.Delete(ctx, "user:1")
+	_, err = cache.Get(ctx, "user:1")
+	assert.Equal(t, domain.ErrorDataNotFound, err, "Deleted key still served")
+}
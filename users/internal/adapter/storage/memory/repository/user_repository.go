@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+)
+
+// UserRepository runs every write in a transaction holding the lock, so reads
+// outside it never see writes that may be rolled back.
+type UserRepository struct {
+	mu sync.RWMutex
+
+	users  map[uint64]domain.User
+	lastID uint64
+	now    func() time.Time
+	// history and logins are oldest first; logins outlive their users.
+	history map[uint64][]string
+	logins  []domain.LoginEvent
+	// invitations are keyed by token hash.
+	invitations      map[string]domain.Invitation
+	lastInvitationID uint64
+}
+
+type txKey struct{}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users:       make(map[uint64]domain.User),
+		now:         time.Now,
+		history:     make(map[uint64][]string),
+		invitations: make(map[string]domain.Invitation),
+	}
+}
+
+// WithinTx joins the outer transaction when nested.
+func (ur *UserRepository) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) == ur {
+		return fn(ctx)
+	}
+
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	users, lastID, history, logins := maps.Clone(ur.users), ur.lastID, maps.Clone(ur.history), ur.logins
+	invitations, lastInvitationID := maps.Clone(ur.invitations), ur.lastInvitationID
+
+	err := fn(context.WithValue(ctx, txKey{}, ur))
+	if err != nil {
+		ur.users, ur.lastID, ur.history, ur.logins = users, lastID, history, logins
+		ur.invitations, ur.lastInvitationID = invitations, lastInvitationID
+	}
+
+	return err
+}
+
+// rlock is a no-op inside a transaction, which already holds the lock.
+func (ur *UserRepository) rlock(ctx context.Context) func() {
+	if ctx.Value(txKey{}) == ur {
+		return func() {}
+	}
+
+	ur.mu.RLock()
+	return ur.mu.RUnlock
+}
+
+func (ur *UserRepository) CreateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	err := ur.WithinTx(ctx, func(ctx context.Context) error {
+		if _, ok := ur.findByEmail(user.Email); ok {
+			return domain.ErrorConflictData
+		}
+
+		ur.lastID++
+		now := ur.now()
+
+		user.ID = ur.lastID
+		if user.Role == "" {
+			user.Role = domain.Reader
+		}
+		user.CreatedAt = now
+		user.UpdatedAt = now
+		user.PasswordChangedAt = now
+
+		ur.users[user.ID] = *user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (ur *UserRepository) GetUserById(ctx context.Context, id uint64) (*domain.User, error) {
+	defer ur.rlock(ctx)()
+
+	user, ok := ur.users[id]
+	if !ok {
+		return nil, domain.ErrorDataNotFound
+	}
+
+	return &user, nil
+}
+
+func (ur *UserRepository) GetUserByIdForUpdate(ctx context.Context, id uint64) (*domain.User, error) {
+	return ur.GetUserById(ctx, id)
+}
+
+func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	defer ur.rlock(ctx)()
+
+	user, ok := ur.findByEmail(email)
+	if !ok {
+		return nil, domain.ErrorDataNotFound
+	}
+
+	return &user, nil
+}
+
+func (ur *UserRepository) ListUsers(ctx context.Context, skip, limit uint64) ([]domain.User, error) {
+	defer ur.rlock(ctx)()
+
+	var users []domain.User
+
+	ids := slices.Sorted(maps.Keys(ur.users))
+	offset := (skip - 1) * limit
+
+	for i := offset; i < uint64(len(ids)) && i < offset+limit; i++ {
+		users = append(users, ur.users[ids[i]])
+	}
+
+	return users, nil
+}
+
+func (ur *UserRepository) AdminExists(ctx context.Context) (bool, error) {
+	defer ur.rlock(ctx)()
+
+	for _, user := range ur.users {
+		if user.Role == domain.Admin {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (ur *UserRepository) UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	err := ur.WithinTx(ctx, func(ctx context.Context) error {
+		existing, ok := ur.users[user.ID]
+		if !ok {
+			return domain.ErrorDataNotFound
+		}
+
+		if user.Email != "" {
+			if other, ok := ur.findByEmail(user.Email); ok && other.ID != user.ID {
+				return domain.ErrorConflictData
+			}
+			existing.Email = user.Email
+		}
+		if user.Name != "" {
+			existing.Name = user.Name
+		}
+		now := ur.now()
+		if user.Password != "" {
+			// Copied so transaction snapshots keep their slice.
+			ur.history[user.ID] = append(slices.Clip(ur.history[user.ID]), existing.Password)
+			existing.Password = user.Password
+			existing.PasswordChangedAt = now
+		}
+		if user.Role != "" {
+			existing.Role = user.Role
+		}
+		existing.UpdatedAt = now
+
+		ur.users[user.ID] = existing
+		*user = existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (ur *UserRepository) DeleteUser(ctx context.Context, id uint64) error {
+	return ur.WithinTx(ctx, func(ctx context.Context) error {
+		if _, ok := ur.users[id]; !ok {
+			return domain.ErrorDataNotFound
+		}
+
+		delete(ur.users, id)
+		delete(ur.history, id)
+		return nil
+	})
+}
+
+func (ur *UserRepository) ListPasswordHistory(ctx context.Context, id uint64, limit uint64) ([]string, error) {
+	defer ur.rlock(ctx)()
+
+	var hashes []string
+
+	history := ur.history[id]
+	for i := len(history) - 1; i >= 0 && uint64(len(hashes)) < limit; i-- {
+		hashes = append(hashes, history[i])
+	}
+
+	return hashes, nil
+}
+
+func (ur *UserRepository) RecordLogin(ctx context.Context, event *domain.LoginEvent) (*domain.LoginEvent, error) {
+	err := ur.WithinTx(ctx, func(ctx context.Context) error {
+		event.ID = uint64(len(ur.logins)) + 1
+		event.CreatedAt = ur.now()
+
+		if event.Success {
+			user, ok := ur.users[event.UserID]
+			if !ok {
+				return domain.ErrorDataNotFound
+			}
+			user.LastLoginAt = event.CreatedAt
+			ur.users[user.ID] = user
+		}
+
+		ur.logins = append(slices.Clip(ur.logins), *event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (ur *UserRepository) ListLoginEvents(ctx context.Context, id uint64, skip, limit uint64) ([]domain.LoginEvent, error) {
+	defer ur.rlock(ctx)()
+
+	var events []domain.LoginEvent
+
+	offset := (skip - 1) * limit
+
+	var matched uint64
+	for i := len(ur.logins) - 1; i >= 0 && matched < offset+limit; i-- {
+		if id != 0 && ur.logins[i].UserID != id {
+			continue
+		}
+		if matched >= offset {
+			events = append(events, ur.logins[i])
+		}
+		matched++
+	}
+
+	return events, nil
+}
+
+func (ur *UserRepository) CreateInvitation(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error) {
+	err := ur.WithinTx(ctx, func(ctx context.Context) error {
+		maps.DeleteFunc(ur.invitations, func(_ string, existing domain.Invitation) bool {
+			return existing.Email == invitation.Email
+		})
+
+		ur.lastInvitationID++
+		invitation.ID = ur.lastInvitationID
+		invitation.CreatedAt = ur.now()
+
+		stored := *invitation
+		stored.Token = ""
+		ur.invitations[invitation.TokenHash] = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (ur *UserRepository) ClaimInvitation(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	var invitation domain.Invitation
+
+	err := ur.WithinTx(ctx, func(ctx context.Context) error {
+		var ok bool
+		invitation, ok = ur.invitations[tokenHash]
+		if !ok {
+			return domain.ErrorDataNotFound
+		}
+
+		delete(ur.invitations, tokenHash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (ur *UserRepository) findByEmail(email string) (domain.User, bool) {
+	for _, user := range ur.users {
+		if user.Email == email {
+			return user, true
+		}
+	}
+	return domain.User{}, false
+}
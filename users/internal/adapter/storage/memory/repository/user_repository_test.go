@@ -0,0 +1,44 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/memory/repository"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port/porttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserRepository(t *testing.T) {
+	porttest.TestUserRepository(t, func(t *testing.T) (port.UserRepository, port.Transactor) {
+		repo := repository.NewUserRepository()
+		return repo, repo
+	})
+}
+
+func TestUserRepository_UncommittedWritesHidden(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewUserRepository()
+	rollback := errors.New("rollback")
+
+	read := make(chan error, 1)
+	err := repo.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := repo.CreateUser(ctx, &domain.User{Name: "Ada", Email: "ada@example.com", Password: "hash"})
+		assert.NoError(t, err)
+
+		go func() {
+			_, err := repo.GetUserByEmail(context.Background(), "ada@example.com")
+			read <- err
+		}()
+		// Give the read a chance to run before the rollback.
+		time.Sleep(10 * time.Millisecond)
+
+		return rollback
+	})
+	assert.Equal(t, rollback, err)
+	assert.Equal(t, domain.ErrorDataNotFound, <-read, "Read saw a write rolled back")
+}
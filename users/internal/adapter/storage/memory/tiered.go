@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+)
+
+// Tiered serves keys with one of prefixes from the local tier for at most
+// localTTL, which bounds how stale another replica's write can look.
+type Tiered struct {
+	local    port.CacheRepository
+	remote   port.CacheRepository
+	localTTL time.Duration
+	prefixes []string
+}
+
+func NewTiered(local, remote port.CacheRepository, localTTL time.Duration, prefixes ...string) port.CacheRepository {
+	return &Tiered{
+		local:    local,
+		remote:   remote,
+		localTTL: localTTL,
+		prefixes: prefixes,
+	}
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := t.remote.Set(ctx, key, value, ttl)
+	if err != nil {
+		return err
+	}
+
+	if t.hot(key) {
+		return t.local.Set(ctx, key, value, t.ttl(ttl))
+	}
+
+	return nil
+}
+
+// SetIfAbsent claims key in the shared tier, which alone sees every replica.
+func (t *Tiered) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	ok, err := t.remote.SetIfAbsent(ctx, key, value, ttl)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	if t.hot(key) {
+		return true, t.local.Set(ctx, key, value, t.ttl(ttl))
+	}
+
+	return true, nil
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if !t.hot(key) {
+		return t.remote.Get(ctx, key)
+	}
+
+	value, err := t.local.Get(ctx, key)
+	if err == nil {
+		return value, nil
+	}
+
+	value, err = t.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The shared tier stays authoritative, so a failed local fill is not an error.
+	_ = t.local.Set(ctx, key, value, t.ttl(0))
+
+	return value, nil
+}
+
+func (t *Tiered) Delete(ctx context.Context, key string) error {
+	return errors.Join(t.remote.Delete(ctx, key), t.local.Delete(ctx, key))
+}
+
+func (t *Tiered) DeleteByPrefix(ctx context.Context, prefix string) error {
+	return errors.Join(t.remote.DeleteByPrefix(ctx, prefix), t.local.DeleteByPrefix(ctx, prefix))
+}
+
+func (t *Tiered) Increment(ctx context.Context, key string) (int64, error) {
+	value, err := t.remote.Increment(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, t.local.Delete(ctx, key)
+}
+
+func (t *Tiered) Close() error {
+	return errors.Join(t.remote.Close(), t.local.Close())
+}
+
+func (t *Tiered) hot(key string) bool {
+	for _, prefix := range t.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ttl caps the local lifetime of an entry at localTTL, when one is set.
+func (t *Tiered) ttl(ttl time.Duration) time.Duration {
+	if t.localTTL <= 0 {
+		return ttl
+	}
+	if ttl <= 0 || ttl > t.localTTL {
+		return t.localTTL
+	}
+	return ttl
+}
@@ -0,0 +1,93 @@
+package migration
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+type Migrator struct {
+	migrate *migrate.Migrate
+	source  source.Driver
+}
+
+type Migration struct {
+	Version    uint
+	Identifier string
+	Applied    bool
+}
+
+// Status Dirty is set when the last migration failed halfway.
+type Status struct {
+	Version    uint
+	Dirty      bool
+	Migrations []Migration
+}
+
+// New needs migrate to be created on source.
+func New(migrate *migrate.Migrate, source source.Driver) *Migrator {
+	return &Migrator{migrate: migrate, source: source}
+}
+
+func (m *Migrator) Status() (*Status, error) {
+	var status Status
+
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, err
+	}
+	status.Version, status.Dirty = version, dirty
+
+	next, nextErr := m.source.First()
+	for nextErr == nil {
+		r, identifier, err := m.source.ReadUp(next)
+		if err != nil {
+			return nil, err
+		}
+		r.Close()
+
+		status.Migrations = append(status.Migrations, Migration{
+			Version:    next,
+			Identifier: identifier,
+			Applied:    next <= version,
+		})
+
+		next, nextErr = m.source.Next(next)
+	}
+	if !errors.Is(nextErr, fs.ErrNotExist) {
+		return nil, nextErr
+	}
+
+	return &status, nil
+}
+
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.migrate.Up())
+}
+
+func (m *Migrator) Down(n int) error {
+	return ignoreNoChange(m.migrate.Steps(-n))
+}
+
+func (m *Migrator) Goto(version uint) error {
+	return ignoreNoChange(m.migrate.Migrate(version))
+}
+
+// Force runs no migration; a version of -1 records that none is applied.
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+func (m *Migrator) Close() error {
+	sourceErr, databaseErr := m.migrate.Close()
+	return errors.Join(sourceErr, databaseErr)
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
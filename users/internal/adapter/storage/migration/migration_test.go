@@ -0,0 +1,84 @@
+package migration_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/migration"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMigrator(t *testing.T) *migration.Migrator {
+	db, err := sqlite.New(context.Background(), config.DB{
+		Connection: "sqlite",
+		Name:       filepath.Join(t.TempDir(), "users.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	migrator, err := db.Migrator()
+	require.NoError(t, err)
+	t.Cleanup(func() { migrator.Close() })
+
+	return migrator
+}
+
+func applied(status *migration.Status) []uint {
+	var versions []uint
+	for _, m := range status.Migrations {
+		if m.Applied {
+			versions = append(versions, m.Version)
+		}
+	}
+	return versions
+}
+
+func TestMigrator(t *testing.T) {
+	migrator := newMigrator(t)
+
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	assert.Zero(t, status.Version)
+	assert.Empty(t, applied(status))
+	require.Len(t, status.Migrations, 7)
+	assert.Equal(t, "create_users_table", status.Migrations[0].Identifier)
+
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Up(), "Up without pending migrations failed")
+
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), status.Version)
+	assert.Equal(t, []uint{1, 2, 3, 4, 5, 6, 7}, applied(status))
+
+	require.NoError(t, migrator.Down(6))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1}, applied(status))
+
+	require.NoError(t, migrator.Goto(2))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), status.Version)
+
+	require.NoError(t, migrator.Force(-1))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.Zero(t, status.Version)
+	assert.False(t, status.Dirty)
+}
+
+func TestMigrator_DownUp(t *testing.T) {
+	migrator := newMigrator(t)
+
+	require.NoError(t, migrator.Up())
+	status, err := migrator.Status()
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Down(int(status.Version)))
+	require.NoError(t, migrator.Up(), "Migrations failed to apply again")
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"embed"
 	"errors"
-	"fmt"
+	"net"
+	"net/url"
+	"strconv"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/migration"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
@@ -25,15 +28,21 @@ type DB struct {
 var migrationsFS embed.FS
 
 func New(ctx context.Context, config config.DB) (*DB, error) {
-	url := fmt.Sprintf("%s://%s:%s@%s:%s/%s?sslmode=disable",
-		config.Connection,
-		config.User,
-		config.Password,
-		config.Host,
-		config.Port,
-		config.Name,
-	)
-	pool, err := pgxpool.New(ctx, url)
+	query := url.Values{}
+	if config.SSLMode != "" {
+		query.Set("sslmode", config.SSLMode)
+	}
+	if config.SSLRootCert != "" {
+		query.Set("sslrootcert", config.SSLRootCert)
+	}
+	dsn := (&url.URL{
+		Scheme:   config.Connection,
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		Path:     "/" + config.Name,
+		RawQuery: query.Encode(),
+	}).String()
+	pool, err := pgxpool.New(ctx, dsn)
 	if err != nil {
 		return &DB{}, err
 	}
@@ -45,28 +54,35 @@ func New(ctx context.Context, config config.DB) (*DB, error) {
 	db := &DB{
 		pool,
 		psql,
-		url,
+		dsn,
 	}
 	return db, nil
 }
-func (db *DB) Migrate() error {
+
+// Migrator holds its own connection until closed.
+func (db *DB) Migrator() (*migration.Migrator, error) {
 	driver, err := iofs.New(migrationsFS, "migrations")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	migrations, err := migrate.NewWithSourceInstance("iofs", driver, db.url)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = migrations.Up()
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+	return migration.New(migrations, driver), nil
+}
+func (db *DB) Migrate() error {
+	migrator, err := db.Migrator()
+	if err != nil {
 		return err
 	}
-	return nil
+	return errors.Join(migrator.Up(), migrator.Close())
 }
 func (db *DB) ErrorCode(err error) string {
 	var pgErr *pgconn.PgError
-	errors.As(err, &pgErr)
+	if !errors.As(err, &pgErr) {
+		return ""
+	}
 	return pgErr.Code
 }
 func (db *DB) Close() {
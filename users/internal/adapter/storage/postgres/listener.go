@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const listenRetryInterval = time.Second
+
+var ErrorListenerClosed = errors.New("listener closed")
+
+// Listener also wakes waiters whenever the connection is lost.
+type Listener struct {
+	db      *DB
+	channel string
+
+	once   sync.Once
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	notify chan struct{}
+	// listening is closed while the LISTEN is in effect.
+	listening chan struct{}
+}
+
+func (db *DB) Listen(channel string) *Listener {
+	return &Listener{
+		db:        db,
+		channel:   channel,
+		done:      make(chan struct{}),
+		notify:    make(chan struct{}),
+		listening: make(chan struct{}),
+	}
+}
+
+// Wait returns once the LISTEN is in effect, so anything committed after it
+// is notified.
+func (l *Listener) Wait(ctx context.Context) (<-chan struct{}, error) {
+	l.once.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		l.cancel = cancel
+		go l.run(ctx)
+	})
+
+	for {
+		l.mu.Lock()
+		notify, listening := l.notify, l.listening
+		l.mu.Unlock()
+
+		select {
+		case <-listening:
+			return notify, nil
+		default:
+		}
+
+		select {
+		case <-listening:
+		case <-notify:
+		case <-l.done:
+			return nil, ErrorListenerClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *Listener) Close() {
+	l.once.Do(func() { close(l.done) })
+	if l.cancel != nil {
+		l.cancel()
+		<-l.done
+	}
+}
+
+func (l *Listener) run(ctx context.Context) {
+	defer close(l.done)
+
+	for {
+		err := l.listen(ctx)
+		l.broadcast()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			select {
+			case <-time.After(listenRetryInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := l.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize())
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	close(l.listening)
+	l.mu.Unlock()
+
+	for {
+		_, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			// The session still holds the LISTEN; drop it rather than
+			// returning it to the pool.
+			conn.Conn().Close(context.Background())
+
+			l.mu.Lock()
+			l.listening = make(chan struct{})
+			l.mu.Unlock()
+			return err
+		}
+		l.broadcast()
+	}
+}
+
+func (l *Listener) broadcast() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
@@ -1 +1,2 @@
-DROP TABLE IF EXISTS users;
\ No newline at end of file
+DROP TABLE IF EXISTS users;
+DROP TYPE IF EXISTS "users_role_enum";
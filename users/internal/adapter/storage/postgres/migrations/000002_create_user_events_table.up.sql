@@ -0,0 +1,40 @@
+CREATE TYPE "user_event_type_enum" AS ENUM ('USER_EVENT_TYPE_CREATED', 'USER_EVENT_TYPE_UPDATED', 'USER_EVENT_TYPE_DELETED');
+
+CREATE TABLE "user_events" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "type" user_event_type_enum NOT NULL,
+    "user_id" BIGINT NOT NULL,
+    "payload" jsonb NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Writers are serialized with a transaction-scoped advisory lock so event ids
+-- become visible in commit order and watchers resuming from an id never skip one.
+CREATE FUNCTION "record_user_event"() RETURNS trigger AS $$
+DECLARE
+    event_id BIGINT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('user_events'));
+
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO "user_events" ("type", "user_id", "payload")
+        VALUES ('USER_EVENT_TYPE_DELETED', OLD.id, to_jsonb(OLD) - 'password')
+        RETURNING id INTO event_id;
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO "user_events" ("type", "user_id", "payload")
+        VALUES ('USER_EVENT_TYPE_UPDATED', NEW.id, to_jsonb(NEW) - 'password')
+        RETURNING id INTO event_id;
+    ELSE
+        INSERT INTO "user_events" ("type", "user_id", "payload")
+        VALUES ('USER_EVENT_TYPE_CREATED', NEW.id, to_jsonb(NEW) - 'password')
+        RETURNING id INTO event_id;
+    END IF;
+
+    PERFORM pg_notify('user_events', event_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "users_record_event"
+    AFTER INSERT OR UPDATE OR DELETE ON "users"
+    FOR EACH ROW EXECUTE FUNCTION "record_user_event"();
@@ -0,0 +1,13 @@
+CREATE TABLE "outbox" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "type" varchar NOT NULL,
+    "user_id" BIGINT NOT NULL,
+    "payload" jsonb NOT NULL,
+    "attempts" integer NOT NULL DEFAULT 0,
+    "last_error" varchar,
+    "available_at" timestamptz NOT NULL DEFAULT (now()),
+    "published_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX "outbox_pending" ON "outbox" ("available_at") WHERE "published_at" IS NULL;
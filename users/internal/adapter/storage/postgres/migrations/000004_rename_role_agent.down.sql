@@ -0,0 +1 @@
+ALTER TYPE "users_role_enum" RENAME VALUE 'ROLE_AGENT' TO 'ROLE_AGEST';
@@ -0,0 +1,4 @@
+-- Renaming the label carries existing agents over. Anything still using
+-- ROLE_AGEST fails once it runs, and the service cannot handle agents until
+-- it has, so apply it before rolling out.
+ALTER TYPE "users_role_enum" RENAME VALUE 'ROLE_AGEST' TO 'ROLE_AGENT';
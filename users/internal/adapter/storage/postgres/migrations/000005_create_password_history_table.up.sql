@@ -0,0 +1,12 @@
+-- Existing passwords count as set now, so none expires on upgrade. Adding the
+-- column with a default does not fire the user event trigger.
+ALTER TABLE "users" ADD COLUMN "password_changed_at" timestamptz NOT NULL DEFAULT (now());
+
+CREATE TABLE "password_history" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" BIGINT NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "password" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX "password_history_user" ON "password_history" ("user_id", "id");
@@ -0,0 +1,9 @@
+DROP TABLE "login_events";
+
+DROP TRIGGER "users_record_event" ON "users";
+
+CREATE TRIGGER "users_record_event"
+    AFTER INSERT OR UPDATE OR DELETE ON "users"
+    FOR EACH ROW EXECUTE FUNCTION "record_user_event"();
+
+ALTER TABLE "users" DROP COLUMN "last_login_at";
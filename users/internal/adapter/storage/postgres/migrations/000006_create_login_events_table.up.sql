@@ -0,0 +1,24 @@
+ALTER TABLE "users" ADD COLUMN "last_login_at" timestamptz;
+
+-- Logging in only sets last_login_at, which is not a change watchers are told
+-- about, so updates record an event only when they set the user columns.
+DROP TRIGGER "users_record_event" ON "users";
+
+CREATE TRIGGER "users_record_event"
+    AFTER INSERT OR DELETE OR UPDATE OF "name", "email", "password", "role", "updated_at" ON "users"
+    FOR EACH ROW EXECUTE FUNCTION "record_user_event"();
+
+-- Events outlive their users for auditing, so user_id is not a foreign key. It
+-- is NULL when the email belongs to no user.
+CREATE TABLE "login_events" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" BIGINT,
+    "email" varchar NOT NULL,
+    "success" boolean NOT NULL,
+    "reason" varchar CHECK ("reason" IN ('LOGIN_FAILURE_UNKNOWN_EMAIL', 'LOGIN_FAILURE_WRONG_PASSWORD')),
+    "ip_address" varchar NOT NULL,
+    "user_agent" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX "login_events_user" ON "login_events" ("user_id", "id");
@@ -0,0 +1,14 @@
+-- Only the SHA-256 of each token is stored. Invitations are deleted once
+-- accepted or replaced by a newer one for the same email.
+CREATE TABLE "invitations" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "email" varchar NOT NULL,
+    "role" users_role_enum NOT NULL,
+    "token_hash" varchar NOT NULL,
+    "invited_by" BIGINT REFERENCES "users" ("id") ON DELETE SET NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX "invitations_token_hash" ON "invitations" ("token_hash");
+CREATE INDEX "invitations_email" ON "invitations" ("email");
@@ -0,0 +1,26 @@
+-- Writers no longer queue on one advisory lock, so event ids can become visible
+-- out of order; watchers wait a moment for a missing id before skipping it.
+CREATE OR REPLACE FUNCTION "record_user_event"() RETURNS trigger AS $$
+DECLARE
+    event_id BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO "user_events" ("type", "user_id", "payload")
+        VALUES ('USER_EVENT_TYPE_DELETED', OLD.id, to_jsonb(OLD) - 'password')
+        RETURNING id INTO event_id;
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO "user_events" ("type", "user_id", "payload")
+        VALUES ('USER_EVENT_TYPE_UPDATED', NEW.id, to_jsonb(NEW) - 'password')
+        RETURNING id INTO event_id;
+    ELSE
+        INSERT INTO "user_events" ("type", "user_id", "payload")
+        VALUES ('USER_EVENT_TYPE_CREATED', NEW.id, to_jsonb(NEW) - 'password')
+        RETURNING id INTO event_id;
+    END IF;
+
+    PERFORM pg_notify('user_events', event_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE INDEX "user_events_created_at" ON "user_events" ("created_at");
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/postgres"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+)
+
+type OutboxRepository struct {
+	db *postgres.DB
+}
+
+func NewOutboxRepository(db *postgres.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (or *OutboxRepository) ClaimPendingEvents(ctx context.Context, limit uint64, lease time.Duration) ([]domain.DomainEvent, error) {
+	var events []domain.DomainEvent
+
+	pending := or.db.Select("id").
+		From("outbox").
+		Where(sq.Eq{"published_at": nil}).
+		Where(sq.LtOrEq{"available_at": time.Now()}).
+		OrderBy("id").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	query := or.db.Update("outbox").
+		Set("available_at", time.Now().Add(lease)).
+		Where(pending.Prefix("id IN (").Suffix(")")).
+		Suffix("RETURNING id, type, user_id, payload, attempts, created_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := or.db.Querier(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event domain.DomainEvent
+
+		err := rows.Scan(&event.ID, &event.Type, &event.UserID, &event.Payload, &event.Attempts, &event.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (or *OutboxRepository) MarkEventPublished(ctx context.Context, id uint64) error {
+	query := or.db.Update("outbox").
+		Set("published_at", time.Now()).
+		Where(sq.Eq{"id": id})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = or.db.Querier(ctx).Exec(ctx, sql, args...)
+	return err
+}
+
+func (or *OutboxRepository) MarkEventFailed(ctx context.Context, id uint64, retryAt time.Time, reason string) error {
+	query := or.db.Update("outbox").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("last_error", reason).
+		Set("available_at", retryAt).
+		Where(sq.Eq{"id": id})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = or.db.Querier(ctx).Exec(ctx, sql, args...)
+	return err
+}
+
+// appendOutboxEvents must run in the transaction of the user write.
+func appendOutboxEvents(ctx context.Context, db *postgres.DB, events ...domain.DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query := db.Insert("outbox").Columns("type", "user_id", "payload")
+	for _, event := range events {
+		query = query.Values(event.Type, event.UserID, event.Payload)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Querier(ctx).Exec(ctx, sql, args...)
+	return err
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/postgres"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+)
+
+const userEventsChannel = "user_events"
+
+type UserEventRepository struct {
+	db       *postgres.DB
+	listener *postgres.Listener
+}
+
+func NewUserEventRepository(db *postgres.DB) *UserEventRepository {
+	return &UserEventRepository{db: db, listener: db.Listen(userEventsChannel)}
+}
+
+// userEventPayload mirrors the users row stored by the record_user_event trigger.
+type userEventPayload struct {
+	ID        uint64      `json:"id"`
+	Name      string      `json:"name"`
+	Email     string      `json:"email"`
+	Role      domain.Role `json:"role"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+
+	PasswordChangedAt time.Time  `json:"password_changed_at"`
+	LastLoginAt       *time.Time `json:"last_login_at"`
+}
+
+func (er *UserEventRepository) ListUserEvents(ctx context.Context, after, limit uint64) ([]domain.UserEvent, error) {
+	var events []domain.UserEvent
+
+	query := er.db.Select("id", "type", "payload", "created_at").
+		From("user_events").
+		Where(sq.Gt{"id": after}).
+		OrderBy("id").
+		Limit(limit)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := er.db.Querier(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event domain.UserEvent
+		var payload []byte
+
+		err := rows.Scan(&event.Sequence, &event.Type, &payload, &event.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+
+		var user userEventPayload
+		if err := json.Unmarshal(payload, &user); err != nil {
+			return nil, err
+		}
+
+		event.User = domain.User{
+			ID:                user.ID,
+			Name:              user.Name,
+			Email:             user.Email,
+			Role:              user.Role,
+			CreatedAt:         user.CreatedAt,
+			UpdatedAt:         user.UpdatedAt,
+			PasswordChangedAt: user.PasswordChangedAt,
+		}
+		if user.LastLoginAt != nil {
+			event.User.LastLoginAt = *user.LastLoginAt
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (er *UserEventRepository) LastUserEventSequence(ctx context.Context) (uint64, error) {
+	query := er.db.Select("COALESCE(MAX(id), 0)").From("user_events")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var sequence uint64
+	err = er.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(&sequence)
+	if err != nil {
+		return 0, err
+	}
+
+	return sequence, nil
+}
+
+func (er *UserEventRepository) PruneUserEvents(ctx context.Context, before time.Time) (int64, error) {
+	query := er.db.Delete("user_events").Where(sq.Lt{"created_at": before})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := er.db.Querier(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (er *UserEventRepository) WaitUserEvents(ctx context.Context, after uint64) error {
+	// Subscribe before checking so a commit in between is not missed.
+	notified, err := er.listener.Wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	last, err := er.LastUserEventSequence(ctx)
+	if err != nil {
+		return err
+	}
+	if last > after {
+		return nil
+	}
+
+	select {
+	case <-notified:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (er *UserEventRepository) Close() {
+	er.listener.Close()
+}
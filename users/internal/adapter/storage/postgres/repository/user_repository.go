@@ -9,8 +9,40 @@ import (
 	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/postgres"
 	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// userColumns are in the order userFields scans them.
+const userColumns = "id, name, email, password, role, created_at, updated_at, password_changed_at, last_login_at"
+
+func userFields(user *domain.User) []any {
+	return []any{&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.PasswordChangedAt, nullTime{&user.LastLoginAt}}
+}
+
+// loginEventColumns are in the order loginEventFields scans them.
+const loginEventColumns = "id, COALESCE(user_id, 0), email, success, COALESCE(reason, ''), ip_address, user_agent, created_at"
+
+func loginEventFields(event *domain.LoginEvent) []any {
+	return []any{&event.ID, &event.UserID, &event.Email, &event.Success, &event.Reason, &event.IPAddress, &event.UserAgent, &event.CreatedAt}
+}
+
+type nullTime struct{ t *time.Time }
+
+func (n nullTime) ScanTimestamptz(v pgtype.Timestamptz) error {
+	*n.t = time.Time{}
+	if v.Valid {
+		*n.t = v.Time
+	}
+	return nil
+}
+
+// invitationColumns are in the order invitationFields scans them.
+const invitationColumns = "id, email, role, token_hash, COALESCE(invited_by, 0), expires_at, created_at"
+
+func invitationFields(invitation *domain.Invitation) []any {
+	return []any{&invitation.ID, &invitation.Email, &invitation.Role, &invitation.TokenHash, &invitation.InvitedBy, &invitation.ExpiresAt, &invitation.CreatedAt}
+}
+
 type UserRepository struct {
 	db *postgres.DB
 }
@@ -20,17 +52,34 @@ func NewUserRepository(db *postgres.DB) *UserRepository {
 }
 
 func (ur *UserRepository) CreateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	// Users without a role get the column default.
+	columns := []string{"name", "email", "password"}
+	values := []any{user.Name, user.Email, user.Password}
+	if user.Role != "" {
+		columns = append(columns, "role")
+		values = append(values, user.Role)
+	}
 
-	query := ur.db.Insert("users").Columns("name", "email", "password").Values(user.Name, user.Email, user.Password).Suffix("RETURNING *")
+	query := ur.db.Insert("users").Columns(columns...).Values(values...).Suffix("RETURNING " + userColumns)
 
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return nil, err
 	}
 
-	err = ur.db.QueryRow(ctx, sql, args...).Scan(
-		&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt,
-	)
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		err := ur.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(userFields(user)...)
+		if err != nil {
+			return err
+		}
+
+		event, err := domain.NewUserRegisteredEvent(user)
+		if err != nil {
+			return err
+		}
+
+		return appendOutboxEvents(ctx, ur.db, event)
+	})
 
 	if err != nil {
 		if errCode := ur.db.ErrorCode(err); errCode == "23505" {
@@ -43,7 +92,18 @@ func (ur *UserRepository) CreateUser(ctx context.Context, user *domain.User) (*d
 }
 
 func (ur *UserRepository) GetUserById(ctx context.Context, id uint64) (*domain.User, error) {
-	query := ur.db.Select("*").From("users").Where(sq.Eq{"id": id}).Limit(1)
+	return ur.getUserById(ctx, id, false)
+}
+
+func (ur *UserRepository) GetUserByIdForUpdate(ctx context.Context, id uint64) (*domain.User, error) {
+	return ur.getUserById(ctx, id, true)
+}
+
+func (ur *UserRepository) getUserById(ctx context.Context, id uint64, forUpdate bool) (*domain.User, error) {
+	query := ur.db.Select(userColumns).From("users").Where(sq.Eq{"id": id}).Limit(1)
+	if forUpdate {
+		query = query.Suffix("FOR UPDATE")
+	}
 
 	sql, args, err := query.ToSql()
 	if err != nil {
@@ -52,8 +112,7 @@ func (ur *UserRepository) GetUserById(ctx context.Context, id uint64) (*domain.U
 
 	var user domain.User
 
-	err = ur.db.QueryRow(ctx, sql, args...).Scan(
-		&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	err = ur.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(userFields(&user)...)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -67,14 +126,13 @@ func (ur *UserRepository) GetUserById(ctx context.Context, id uint64) (*domain.U
 }
 
 func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
-	query := ur.db.Select("*").From("users").Where(sq.Eq{"email": email}).Limit(1)
+	query := ur.db.Select(userColumns).From("users").Where(sq.Eq{"email": email}).Limit(1)
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return nil, err
 	}
 	var user domain.User
-	err = ur.db.QueryRow(ctx, sql, args...).Scan(
-		&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	err = ur.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(userFields(&user)...)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -89,7 +147,7 @@ func (ur *UserRepository) ListUsers(ctx context.Context, skip, limit uint64) ([]
 	var user domain.User
 	var users []domain.User
 
-	query := ur.db.Select("*").
+	query := ur.db.Select(userColumns).
 		From("users").
 		OrderBy("id").
 		Limit(limit).
@@ -100,22 +158,14 @@ func (ur *UserRepository) ListUsers(ctx context.Context, skip, limit uint64) ([]
 		return nil, err
 	}
 
-	rows, err := ur.db.Query(ctx, sql, args...)
+	rows, err := ur.db.Querier(ctx).Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Email,
-			&user.Password,
-			&user.Role,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		err := rows.Scan(userFields(&user)...)
 		if err != nil {
 			return nil, err
 		}
@@ -126,27 +176,102 @@ func (ur *UserRepository) ListUsers(ctx context.Context, skip, limit uint64) ([]
 	return users, nil
 }
 
+const adminLockKey = 0x75736572
+
+func (ur *UserRepository) AdminExists(ctx context.Context) (bool, error) {
+	_, err := ur.db.Querier(ctx).Exec(ctx, "SELECT pg_advisory_xact_lock($1)", adminLockKey)
+	if err != nil {
+		return false, err
+	}
+
+	query := ur.db.Select("1").From("users").Where(sq.Eq{"role": domain.Admin}).Limit(1).Prefix("SELECT EXISTS (").Suffix(")")
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = ur.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(&exists)
+	return exists, err
+}
+
 func (ur *UserRepository) UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	now := time.Now()
 
 	query := ur.db.Update("users").
-		Set("name", sq.Expr("COALESCE(?, name)", user.Name)).
-		Set("email", sq.Expr("COALESCE(?, email)", user.Email)).
-		Set("password", sq.Expr("COALESCE(?, password)", user.Password)).
-		Set("role", sq.Expr("COALESCE(?, role)", user.Role)).
-		Set("updated_at", time.Now()).
+		Set("name", sq.Expr("COALESCE(NULLIF(?, ''), name)", user.Name)).
+		Set("email", sq.Expr("COALESCE(NULLIF(?, ''), email)", user.Email)).
+		Set("password", sq.Expr("COALESCE(NULLIF(?, ''), password)", user.Password)).
+		Set("role", sq.Expr("COALESCE(NULLIF(?, '')::users_role_enum, role)", user.Role)).
+		Set("updated_at", now).
 		Where(sq.Eq{"id": user.ID}).
-		Suffix("RETURNING *")
+		Suffix("RETURNING " + userColumns)
+	if user.Password != "" {
+		query = query.Set("password_changed_at", now)
+	}
 
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return nil, err
 	}
-	_, err = ur.db.Exec(ctx, sql, args...)
+
+	// The replaced hash is copied before the update overwrites it.
+	historyQuery := ur.db.Insert("password_history").
+		Columns("user_id", "password").
+		Select(sq.Select("id", "password").From("users").Where(sq.Eq{"id": user.ID}))
+
+	historySql, historyArgs, err := historyQuery.ToSql()
 	if err != nil {
 		return nil, err
 	}
-	err = ur.db.QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+
+	lockQuery := ur.db.Select("role").From("users").Where(sq.Eq{"id": user.ID}).Suffix("FOR UPDATE")
+
+	lockSql, lockArgs, err := lockQuery.ToSql()
 	if err != nil {
+		return nil, err
+	}
+
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		var oldRole domain.Role
+
+		err := ur.db.Querier(ctx).QueryRow(ctx, lockSql, lockArgs...).Scan(&oldRole)
+		if err != nil {
+			return err
+		}
+
+		if user.Password != "" {
+			_, err = ur.db.Querier(ctx).Exec(ctx, historySql, historyArgs...)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = ur.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(userFields(user)...)
+		if err != nil {
+			return err
+		}
+
+		event, err := domain.NewUserUpdatedEvent(user)
+		if err != nil {
+			return err
+		}
+		events := []domain.DomainEvent{event}
+
+		if oldRole != user.Role {
+			event, err := domain.NewRoleChangedEvent(user.ID, oldRole, user.Role)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+
+		return appendOutboxEvents(ctx, ur.db, events...)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrorDataNotFound
+		}
 		if errCode := ur.db.ErrorCode(err); errCode == "23505" {
 			return nil, domain.ErrorConflictData
 		}
@@ -157,17 +282,206 @@ func (ur *UserRepository) UpdateUser(ctx context.Context, user *domain.User) (*d
 
 func (ur *UserRepository) DeleteUser(ctx context.Context, id uint64) error {
 	query := ur.db.Delete("users").
-		Where(sq.Eq{"id": id})
+		Where(sq.Eq{"id": id}).
+		Suffix("RETURNING " + userColumns)
 
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = ur.db.Exec(ctx, sql, args...)
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		var user domain.User
+
+		err := ur.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(userFields(&user)...)
+		if err != nil {
+			return err
+		}
+
+		event, err := domain.NewUserDeletedEvent(&user)
+		if err != nil {
+			return err
+		}
+
+		return appendOutboxEvents(ctx, ur.db, event)
+	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrorDataNotFound
+		}
 		return err
 	}
 
 	return nil
 }
+
+func (ur *UserRepository) ListPasswordHistory(ctx context.Context, id uint64, limit uint64) ([]string, error) {
+	var hashes []string
+
+	query := ur.db.Select("password").
+		From("password_history").
+		Where(sq.Eq{"user_id": id}).
+		OrderBy("id DESC").
+		Limit(limit)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ur.db.Querier(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+func (ur *UserRepository) RecordLogin(ctx context.Context, event *domain.LoginEvent) (*domain.LoginEvent, error) {
+	now := time.Now()
+
+	query := ur.db.Insert("login_events").
+		Columns("user_id", "email", "success", "reason", "ip_address", "user_agent", "created_at").
+		Values(sq.Expr("NULLIF(?::bigint, 0)", event.UserID), event.Email, event.Success, sq.Expr("NULLIF(?, '')", event.Reason), event.IPAddress, event.UserAgent, now).
+		Suffix("RETURNING id, created_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only last_login_at is set, which the user event trigger ignores.
+	lastLoginQuery := ur.db.Update("users").
+		Set("last_login_at", now).
+		Where(sq.Eq{"id": event.UserID})
+
+	lastLoginSql, lastLoginArgs, err := lastLoginQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		err := ur.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(&event.ID, &event.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if !event.Success {
+			return nil
+		}
+
+		tag, err := ur.db.Querier(ctx).Exec(ctx, lastLoginSql, lastLoginArgs...)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrorDataNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (ur *UserRepository) ListLoginEvents(ctx context.Context, id uint64, skip, limit uint64) ([]domain.LoginEvent, error) {
+	var events []domain.LoginEvent
+
+	query := ur.db.Select(loginEventColumns).
+		From("login_events").
+		OrderBy("id DESC").
+		Limit(limit).
+		Offset((skip - 1) * limit)
+	if id != 0 {
+		query = query.Where(sq.Eq{"user_id": id})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ur.db.Querier(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event domain.LoginEvent
+		if err := rows.Scan(loginEventFields(&event)...); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (ur *UserRepository) CreateInvitation(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error) {
+	deleteQuery := ur.db.Delete("invitations").Where(sq.Eq{"email": invitation.Email})
+
+	deleteSql, deleteArgs, err := deleteQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := ur.db.Insert("invitations").
+		Columns("email", "role", "token_hash", "invited_by", "expires_at").
+		Values(invitation.Email, invitation.Role, invitation.TokenHash, sq.Expr("NULLIF(?::bigint, 0)", invitation.InvitedBy), invitation.ExpiresAt).
+		Suffix("RETURNING id, created_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := ur.db.Querier(ctx).Exec(ctx, deleteSql, deleteArgs...)
+		if err != nil {
+			return err
+		}
+
+		return ur.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(&invitation.ID, &invitation.CreatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (ur *UserRepository) ClaimInvitation(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	query := ur.db.Delete("invitations").
+		Where(sq.Eq{"token_hash": tokenHash}).
+		Suffix("RETURNING " + invitationColumns)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var invitation domain.Invitation
+
+	err = ur.db.Querier(ctx).QueryRow(ctx, sql, args...).Scan(invitationFields(&invitation)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrorDataNotFound
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
@@ -0,0 +1,152 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/postgres"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/postgres/repository"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port/porttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDB connects to the database named by the TEST_DB_* variables,
+// skipping the test when TEST_DB_HOST is not set.
+func newTestDB(t *testing.T) *postgres.DB {
+	if os.Getenv("TEST_DB_HOST") == "" {
+		t.Skip("TEST_DB_HOST not set")
+	}
+
+	dbPort, err := strconv.Atoi(os.Getenv("TEST_DB_PORT"))
+	require.NoError(t, err)
+
+	db, err := postgres.New(context.Background(), config.DB{
+		Connection: "postgres",
+		Host:       os.Getenv("TEST_DB_HOST"),
+		Port:       dbPort,
+		Name:       os.Getenv("TEST_DB_NAME"),
+		User:       os.Getenv("TEST_DB_USER"),
+		Password:   os.Getenv("TEST_DB_PASSWORD"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+// TestMigrations rolls every migration back and applies them again, leaving
+// the database migrated.
+func TestMigrations(t *testing.T) {
+	db := newTestDB(t)
+
+	migrator, err := db.Migrator()
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	require.NoError(t, migrator.Up())
+	status, err := migrator.Status()
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Down(int(status.Version)))
+	require.NoError(t, migrator.Up(), "Migrations failed to apply again")
+}
+
+// TestMigrations_RoleAgentRenamed checks that agents stored under the
+// misspelled ROLE_AGEST before migration 4 read back as domain.Agent.
+func TestMigrations_RoleAgentRenamed(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	migrator, err := db.Migrator()
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	require.NoError(t, migrator.Goto(3))
+	_, err = db.Exec(ctx, `TRUNCATE "users", "user_events", "outbox" RESTART IDENTITY`)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `INSERT INTO "users" ("name", "email", "password", "role") VALUES ('Ada', 'ada@example.com', 'hash', 'ROLE_AGEST')`)
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Goto(4))
+	var role string
+	require.NoError(t, db.QueryRow(ctx, `SELECT "role" FROM "users" WHERE "email" = 'ada@example.com'`).Scan(&role))
+	assert.Equal(t, string(domain.Agent), role, "Stored agent not renamed")
+
+	require.NoError(t, migrator.Up())
+}
+
+// TestUserRepository migrates and empties the test database.
+func TestUserRepository(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	require.NoError(t, db.Migrate())
+
+	porttest.TestUserRepository(t, func(t *testing.T) (port.UserRepository, port.Transactor) {
+		_, err := db.Exec(ctx, `TRUNCATE "users", "user_events", "outbox", "password_history", "login_events", "invitations" RESTART IDENTITY`)
+		require.NoError(t, err)
+
+		return repository.NewUserRepository(db), db
+	})
+}
+
+func TestUserEventRepository_WaitUserEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	db := newTestDB(t)
+
+	require.NoError(t, db.Migrate())
+	_, err := db.Exec(ctx, `TRUNCATE "users", "user_events", "outbox", "password_history", "login_events", "invitations" RESTART IDENTITY`)
+	require.NoError(t, err)
+
+	users := repository.NewUserRepository(db)
+	events := repository.NewUserEventRepository(db)
+	defer events.Close()
+
+	// The event is committed while the listener is still connecting.
+	waited := make(chan error, 1)
+	go func() { waited <- events.WaitUserEvents(ctx, 0) }()
+	_, err = users.CreateUser(ctx, &domain.User{Name: "Ada", Email: "ada@example.com", Password: "secret"})
+	require.NoError(t, err)
+
+	assert.NoError(t, <-waited, "Committed event not seen")
+}
+
+func TestUserEventRepository_PruneUserEvents(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	require.NoError(t, db.Migrate())
+	_, err := db.Exec(ctx, `TRUNCATE "users", "user_events", "outbox", "password_history", "login_events", "invitations" RESTART IDENTITY`)
+	require.NoError(t, err)
+
+	users := repository.NewUserRepository(db)
+	events := repository.NewUserEventRepository(db)
+	defer events.Close()
+
+	user, err := users.CreateUser(ctx, &domain.User{Name: "Ada", Email: "ada@example.com", Password: "secret"})
+	require.NoError(t, err)
+	_, err = users.RecordLogin(ctx, &domain.LoginEvent{UserID: user.ID, Email: user.Email, Success: true})
+	require.NoError(t, err)
+	require.NoError(t, users.DeleteUser(ctx, user.ID))
+
+	feed, err := events.ListUserEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, feed, 2)
+	assert.True(t, user.PasswordChangedAt.Equal(feed[0].User.PasswordChangedAt), "Password change time mismatch")
+	assert.False(t, feed[1].User.LastLoginAt.IsZero(), "Login time missing")
+
+	pruned, err := events.PruneUserEvents(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), pruned)
+	feed, err = events.ListUserEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, feed, "Pruned events listed")
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/redis/go-redis/v9"
+)
+
+const invalidationChannel = "users:invalidations"
+
+type InvalidationBus struct {
+	client *redis.Client
+}
+
+type invalidationMessage struct {
+	Kind   domain.InvalidationKind `json:"kind"`
+	Key    string                  `json:"key"`
+	Origin string                  `json:"origin"`
+}
+
+func NewInvalidationBus(ctx context.Context, config *config.Redis) (port.InvalidationBus, error) {
+	options, err := newOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(options)
+
+	_, err = client.Ping(ctx).Result()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &InvalidationBus{client: client}, nil
+}
+
+func (b *InvalidationBus) Publish(ctx context.Context, invalidation domain.Invalidation) error {
+	payload, err := json.Marshal(invalidationMessage(invalidation))
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(ctx, invalidationChannel, payload).Err()
+}
+
+// Subscribe reports every resubscription as an invalidation of all keys.
+func (b *InvalidationBus) Subscribe(ctx context.Context, handler func(domain.Invalidation)) error {
+	pubsub := b.client.Subscribe(ctx, invalidationChannel)
+
+	_, err := pubsub.Receive(ctx)
+	if err != nil {
+		pubsub.Close()
+		return err
+	}
+
+	messages := pubsub.ChannelWithSubscriptions()
+
+	go func() {
+		defer pubsub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				switch message := message.(type) {
+				case *redis.Subscription:
+					if message.Kind == "subscribe" {
+						handler(domain.Invalidation{Kind: domain.InvalidatePrefix, Key: "*"})
+					}
+				case *redis.Message:
+					var invalidation invalidationMessage
+					if err := json.Unmarshal([]byte(message.Payload), &invalidation); err != nil {
+						continue
+					}
+					handler(domain.Invalidation(invalidation))
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (b *InvalidationBus) Close() error {
+	return b.client.Close()
+}
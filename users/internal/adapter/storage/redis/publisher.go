@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ port.EventPublisher = (*EventPublisher)(nil)
+
+// EventPublisher consumers deduplicate on the id field.
+type EventPublisher struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+func NewEventPublisher(config *config.Redis, stream string, maxLen int) (*EventPublisher, error) {
+	options, err := newOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventPublisher{client: redis.NewClient(options), stream: stream, maxLen: int64(maxLen)}, nil
+}
+
+func (p *EventPublisher) Publish(ctx context.Context, event domain.DomainEvent) error {
+	return p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: p.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"id":          strconv.FormatUint(event.ID, 10),
+			"type":        string(event.Type),
+			"user_id":     strconv.FormatUint(event.UserID, 10),
+			"payload":     event.Payload,
+			"occurred_at": event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		},
+	}).Err()
+}
+
+func (p *EventPublisher) Close() error {
+	return p.client.Close()
+}
@@ -2,40 +2,79 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/tlsconfig"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
 	"github.com/OzkrOssa/radiusx-users/internal/core/port"
 	"github.com/redis/go-redis/v9"
 )
 
+var _ port.CacheRepository = (*Redis)(nil)
+
 type Redis struct {
 	client *redis.Client
 }
 
-func New(ctx context.Context, config *config.Redis) (port.CacheRepository, error) {
-	options := &redis.Options{
-		Addr:     config.Host + ":" + config.Port,
-		Password: config.Password,
-		DB:       0,
+func New(ctx context.Context, config *config.Redis) (*Redis, error) {
+	r, err := NewLazy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Ping(ctx); err != nil {
+		r.Close()
+		return nil, err
 	}
 
-	client := redis.NewClient(options)
+	return r, nil
+}
 
-	_, err := client.Ping(ctx).Result()
+// NewLazy is New without the initial ping.
+func NewLazy(config *config.Redis) (*Redis, error) {
+	options, err := newOptions(config)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Redis{client: client}, nil
+	return &Redis{client: redis.NewClient(options)}, nil
+}
+
+func newOptions(config *config.Redis) (*redis.Options, error) {
+	options := &redis.Options{
+		Addr:     net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		Password: config.Password,
+		DB:       0,
+	}
+
+	if config.TLS {
+		tlsConfig, err := tlsconfig.Client(config.TLSCAFile, config.Host)
+		if err != nil {
+			return nil, err
+		}
+		options.TLSConfig = tlsConfig
+	}
+
+	return options, nil
 }
 
 func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
 	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
+func (r *Redis) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, ttl).Result()
+}
+
 func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
 	res, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrorDataNotFound
+	}
 	bytes := []byte(res)
 	return bytes, err
 }
@@ -55,8 +94,8 @@ func (r *Redis) DeleteByPrefix(ctx context.Context, prefix string) error {
 			return err
 		}
 
-		for _, key := range keys {
-			err := r.client.Del(ctx, key).Err()
+		if len(keys) > 0 {
+			err := r.client.Unlink(ctx, keys...).Err()
 			if err != nil {
 				return err
 			}
@@ -70,6 +109,14 @@ func (r *Redis) DeleteByPrefix(ctx context.Context, prefix string) error {
 	return nil
 }
 
+func (r *Redis) Increment(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
+
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 func (r *Redis) Close() error {
 	return r.client.Close()
 }
@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/migration"
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	sqlitedriver "modernc.org/sqlite"
+)
+
+type DB struct {
+	*sql.DB
+	squirrel.StatementBuilderType
+	dsn string
+}
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// New makes transactions take the write lock when they begin, which gives
+// reads inside them the guarantees of SELECT ... FOR UPDATE.
+func New(ctx context.Context, config config.DB) (*DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+
+	dsn := fmt.Sprintf("file:%s?%s", config.Name, params.Encode())
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return &DB{}, err
+	}
+	err = db.PingContext(ctx)
+	if err != nil {
+		return &DB{}, err
+	}
+	return &DB{
+		db,
+		squirrel.StatementBuilder.PlaceholderFormat(squirrel.Question),
+		dsn,
+	}, nil
+}
+
+func (db *DB) Migrator() (*migration.Migrator, error) {
+	source, err := iofs.New(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sql.Open("sqlite", db.dsn)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := migratesqlite.WithInstance(conn, &migratesqlite.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	migrations, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return migration.New(migrations, source), nil
+}
+
+func (db *DB) Migrate() error {
+	migrator, err := db.Migrator()
+	if err != nil {
+		return err
+	}
+	return errors.Join(migrator.Up(), migrator.Close())
+}
+
+func (db *DB) ErrorCode(err error) int {
+	var sqliteErr *sqlitedriver.Error
+	if !errors.As(err, &sqliteErr) {
+		return 0
+	}
+	return sqliteErr.Code()
+}
+
+func (db *DB) Close() {
+	db.DB.Close()
+}
@@ -0,0 +1,11 @@
+CREATE TABLE "users" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "name" TEXT NOT NULL,
+    "email" TEXT NOT NULL,
+    "password" TEXT NOT NULL,
+    "role" TEXT NOT NULL DEFAULT 'ROLE_READER' CHECK ("role" IN ('ROLE_ADMIN', 'ROLE_AGENT', 'ROLE_READER')),
+    "created_at" DATETIME NOT NULL,
+    "updated_at" DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX "email" ON "users" ("email");
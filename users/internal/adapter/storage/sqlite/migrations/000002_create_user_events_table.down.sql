@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS "users_record_deleted";
+DROP TRIGGER IF EXISTS "users_record_updated";
+DROP TRIGGER IF EXISTS "users_record_created";
+DROP TABLE IF EXISTS "user_events";
@@ -0,0 +1,34 @@
+CREATE TABLE "user_events" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "type" TEXT NOT NULL CHECK ("type" IN ('USER_EVENT_TYPE_CREATED', 'USER_EVENT_TYPE_UPDATED', 'USER_EVENT_TYPE_DELETED')),
+    "user_id" INTEGER NOT NULL,
+    "payload" TEXT NOT NULL,
+    "created_at" DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+-- SQLite has a single writer, so event ids are assigned in commit order and
+-- watchers resuming from an id never skip one. Timestamps are stored as
+-- "YYYY-MM-DD HH:MM:SS+00:00"; replacing the space gives RFC 3339 in the payload.
+CREATE TRIGGER "users_record_created" AFTER INSERT ON "users"
+BEGIN
+    INSERT INTO "user_events" ("type", "user_id", "payload")
+    VALUES ('USER_EVENT_TYPE_CREATED', NEW.id, json_object(
+        'id', NEW.id, 'name', NEW.name, 'email', NEW.email, 'role', NEW.role,
+        'created_at', replace(NEW.created_at, ' ', 'T'), 'updated_at', replace(NEW.updated_at, ' ', 'T')));
+END;
+
+CREATE TRIGGER "users_record_updated" AFTER UPDATE ON "users"
+BEGIN
+    INSERT INTO "user_events" ("type", "user_id", "payload")
+    VALUES ('USER_EVENT_TYPE_UPDATED', NEW.id, json_object(
+        'id', NEW.id, 'name', NEW.name, 'email', NEW.email, 'role', NEW.role,
+        'created_at', replace(NEW.created_at, ' ', 'T'), 'updated_at', replace(NEW.updated_at, ' ', 'T')));
+END;
+
+CREATE TRIGGER "users_record_deleted" AFTER DELETE ON "users"
+BEGIN
+    INSERT INTO "user_events" ("type", "user_id", "payload")
+    VALUES ('USER_EVENT_TYPE_DELETED', OLD.id, json_object(
+        'id', OLD.id, 'name', OLD.name, 'email', OLD.email, 'role', OLD.role,
+        'created_at', replace(OLD.created_at, ' ', 'T'), 'updated_at', replace(OLD.updated_at, ' ', 'T')));
+END;
@@ -0,0 +1,13 @@
+CREATE TABLE "outbox" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "type" TEXT NOT NULL,
+    "user_id" INTEGER NOT NULL,
+    "payload" BLOB NOT NULL,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "last_error" TEXT,
+    "available_at" DATETIME NOT NULL,
+    "published_at" DATETIME,
+    "created_at" DATETIME NOT NULL
+);
+
+CREATE INDEX "outbox_pending" ON "outbox" ("available_at") WHERE "published_at" IS NULL;
@@ -0,0 +1,25 @@
+-- SQLite cannot add a column defaulting to the current time, so existing rows
+-- are filled in with the update trigger dropped: existing passwords count as
+-- set now without recording a user event for every row.
+ALTER TABLE "users" ADD COLUMN "password_changed_at" DATETIME;
+
+DROP TRIGGER "users_record_updated";
+
+UPDATE "users" SET "password_changed_at" = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
+
+CREATE TRIGGER "users_record_updated" AFTER UPDATE ON "users"
+BEGIN
+    INSERT INTO "user_events" ("type", "user_id", "payload")
+    VALUES ('USER_EVENT_TYPE_UPDATED', NEW.id, json_object(
+        'id', NEW.id, 'name', NEW.name, 'email', NEW.email, 'role', NEW.role,
+        'created_at', replace(NEW.created_at, ' ', 'T'), 'updated_at', replace(NEW.updated_at, ' ', 'T')));
+END;
+
+CREATE TABLE "password_history" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "user_id" INTEGER NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "password" TEXT NOT NULL,
+    "created_at" DATETIME NOT NULL
+);
+
+CREATE INDEX "password_history_user" ON "password_history" ("user_id", "id");
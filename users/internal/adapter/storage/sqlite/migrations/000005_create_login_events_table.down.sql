@@ -0,0 +1,13 @@
+DROP TABLE "login_events";
+
+DROP TRIGGER "users_record_updated";
+
+CREATE TRIGGER "users_record_updated" AFTER UPDATE ON "users"
+BEGIN
+    INSERT INTO "user_events" ("type", "user_id", "payload")
+    VALUES ('USER_EVENT_TYPE_UPDATED', NEW.id, json_object(
+        'id', NEW.id, 'name', NEW.name, 'email', NEW.email, 'role', NEW.role,
+        'created_at', replace(NEW.created_at, ' ', 'T'), 'updated_at', replace(NEW.updated_at, ' ', 'T')));
+END;
+
+ALTER TABLE "users" DROP COLUMN "last_login_at";
@@ -0,0 +1,28 @@
+ALTER TABLE "users" ADD COLUMN "last_login_at" DATETIME;
+
+-- Logging in only sets last_login_at, which is not a change watchers are told
+-- about, so updates record an event only when they set the user columns.
+DROP TRIGGER "users_record_updated";
+
+CREATE TRIGGER "users_record_updated" AFTER UPDATE OF "name", "email", "password", "role", "updated_at" ON "users"
+BEGIN
+    INSERT INTO "user_events" ("type", "user_id", "payload")
+    VALUES ('USER_EVENT_TYPE_UPDATED', NEW.id, json_object(
+        'id', NEW.id, 'name', NEW.name, 'email', NEW.email, 'role', NEW.role,
+        'created_at', replace(NEW.created_at, ' ', 'T'), 'updated_at', replace(NEW.updated_at, ' ', 'T')));
+END;
+
+-- Events outlive their users for auditing, so user_id is not a foreign key. It
+-- is NULL when the email belongs to no user.
+CREATE TABLE "login_events" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "user_id" INTEGER,
+    "email" TEXT NOT NULL,
+    "success" BOOLEAN NOT NULL,
+    "reason" TEXT CHECK ("reason" IN ('LOGIN_FAILURE_UNKNOWN_EMAIL', 'LOGIN_FAILURE_WRONG_PASSWORD')),
+    "ip_address" TEXT NOT NULL,
+    "user_agent" TEXT NOT NULL,
+    "created_at" DATETIME NOT NULL
+);
+
+CREATE INDEX "login_events_user" ON "login_events" ("user_id", "id");
@@ -0,0 +1,14 @@
+-- Only the SHA-256 of each token is stored. Invitations are deleted once
+-- accepted or replaced by a newer one for the same email.
+CREATE TABLE "invitations" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "email" TEXT NOT NULL,
+    "role" TEXT NOT NULL CHECK ("role" IN ('ROLE_ADMIN', 'ROLE_AGENT', 'ROLE_READER')),
+    "token_hash" TEXT NOT NULL,
+    "invited_by" INTEGER REFERENCES "users" ("id") ON DELETE SET NULL,
+    "expires_at" DATETIME NOT NULL,
+    "created_at" DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX "invitations_token_hash" ON "invitations" ("token_hash");
+CREATE INDEX "invitations_email" ON "invitations" ("email");
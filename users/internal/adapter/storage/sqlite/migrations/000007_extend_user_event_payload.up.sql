@@ -0,0 +1,33 @@
+-- Payloads carry every users column but the password, like the Postgres ones.
+DROP TRIGGER "users_record_created";
+DROP TRIGGER "users_record_updated";
+DROP TRIGGER "users_record_deleted";
+
+CREATE TRIGGER "users_record_created" AFTER INSERT ON "users"
+BEGIN
+    INSERT INTO "user_events" ("type", "user_id", "payload")
+    VALUES ('USER_EVENT_TYPE_CREATED', NEW.id, json_object(
+        'id', NEW.id, 'name', NEW.name, 'email', NEW.email, 'role', NEW.role,
+        'created_at', replace(NEW.created_at, ' ', 'T'), 'updated_at', replace(NEW.updated_at, ' ', 'T'),
+        'password_changed_at', replace(NEW.password_changed_at, ' ', 'T'), 'last_login_at', replace(NEW.last_login_at, ' ', 'T')));
+END;
+
+CREATE TRIGGER "users_record_updated" AFTER UPDATE OF "name", "email", "password", "role", "updated_at" ON "users"
+BEGIN
+    INSERT INTO "user_events" ("type", "user_id", "payload")
+    VALUES ('USER_EVENT_TYPE_UPDATED', NEW.id, json_object(
+        'id', NEW.id, 'name', NEW.name, 'email', NEW.email, 'role', NEW.role,
+        'created_at', replace(NEW.created_at, ' ', 'T'), 'updated_at', replace(NEW.updated_at, ' ', 'T'),
+        'password_changed_at', replace(NEW.password_changed_at, ' ', 'T'), 'last_login_at', replace(NEW.last_login_at, ' ', 'T')));
+END;
+
+CREATE TRIGGER "users_record_deleted" AFTER DELETE ON "users"
+BEGIN
+    INSERT INTO "user_events" ("type", "user_id", "payload")
+    VALUES ('USER_EVENT_TYPE_DELETED', OLD.id, json_object(
+        'id', OLD.id, 'name', OLD.name, 'email', OLD.email, 'role', OLD.role,
+        'created_at', replace(OLD.created_at, ' ', 'T'), 'updated_at', replace(OLD.updated_at, ' ', 'T'),
+        'password_changed_at', replace(OLD.password_changed_at, ' ', 'T'), 'last_login_at', replace(OLD.last_login_at, ' ', 'T')));
+END;
+
+CREATE INDEX "user_events_created_at" ON "user_events" ("created_at");
@@ -0,0 +1,484 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/sqlite"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// userColumns are in the order userFields scans them.
+const userColumns = "id, name, email, password, role, created_at, updated_at, password_changed_at, last_login_at"
+
+func userFields(user *domain.User) []any {
+	return []any{&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.PasswordChangedAt, nullTime{&user.LastLoginAt}}
+}
+
+// loginEventColumns are in the order loginEventFields scans them.
+const loginEventColumns = "id, COALESCE(user_id, 0), email, success, COALESCE(reason, ''), ip_address, user_agent, created_at"
+
+func loginEventFields(event *domain.LoginEvent) []any {
+	return []any{&event.ID, &event.UserID, &event.Email, &event.Success, &event.Reason, &event.IPAddress, &event.UserAgent, &event.CreatedAt}
+}
+
+type nullTime struct{ t *time.Time }
+
+func (n nullTime) Scan(src any) error {
+	var value sql.NullTime
+	if err := value.Scan(src); err != nil {
+		return err
+	}
+	*n.t = value.Time
+	return nil
+}
+
+// invitationColumns are in the order invitationFields scans them.
+const invitationColumns = "id, email, role, token_hash, COALESCE(invited_by, 0), expires_at, created_at"
+
+func invitationFields(invitation *domain.Invitation) []any {
+	return []any{&invitation.ID, &invitation.Email, &invitation.Role, &invitation.TokenHash, &invitation.InvitedBy, &invitation.ExpiresAt, &invitation.CreatedAt}
+}
+
+type UserRepository struct {
+	db *sqlite.DB
+}
+
+func NewUserRepository(db *sqlite.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (ur *UserRepository) CreateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	now := time.Now().UTC()
+
+	// Users without a role get the column default.
+	columns := []string{"name", "email", "password", "created_at", "updated_at", "password_changed_at"}
+	values := []any{user.Name, user.Email, user.Password, now, now, now}
+	if user.Role != "" {
+		columns = append(columns, "role")
+		values = append(values, user.Role)
+	}
+
+	query := ur.db.Insert("users").
+		Columns(columns...).
+		Values(values...).
+		Suffix("RETURNING " + userColumns)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		err := ur.db.Querier(ctx).QueryRowContext(ctx, sqlStr, args...).Scan(userFields(user)...)
+		if err != nil {
+			return err
+		}
+
+		event, err := domain.NewUserRegisteredEvent(user)
+		if err != nil {
+			return err
+		}
+
+		return appendOutboxEvents(ctx, ur.db, event)
+	})
+
+	if err != nil {
+		if errCode := ur.db.ErrorCode(err); errCode == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return nil, domain.ErrorConflictData
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (ur *UserRepository) GetUserById(ctx context.Context, id uint64) (*domain.User, error) {
+	query := ur.db.Select(userColumns).From("users").Where(sq.Eq{"id": id}).Limit(1)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var user domain.User
+
+	err = ur.db.Querier(ctx).QueryRowContext(ctx, sqlStr, args...).Scan(userFields(&user)...)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrorDataNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUserByIdForUpdate needs no row lock: the transaction in ctx holds the
+// write lock.
+func (ur *UserRepository) GetUserByIdForUpdate(ctx context.Context, id uint64) (*domain.User, error) {
+	return ur.GetUserById(ctx, id)
+}
+
+func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	query := ur.db.Select(userColumns).From("users").Where(sq.Eq{"email": email}).Limit(1)
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var user domain.User
+	err = ur.db.Querier(ctx).QueryRowContext(ctx, sqlStr, args...).Scan(userFields(&user)...)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrorDataNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (ur *UserRepository) ListUsers(ctx context.Context, skip, limit uint64) ([]domain.User, error) {
+	var user domain.User
+	var users []domain.User
+
+	query := ur.db.Select(userColumns).
+		From("users").
+		OrderBy("id").
+		Limit(limit).
+		Offset((skip - 1) * limit)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ur.db.Querier(ctx).QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(userFields(&user)...)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (ur *UserRepository) AdminExists(ctx context.Context) (bool, error) {
+	query := ur.db.Select("1").From("users").Where(sq.Eq{"role": domain.Admin}).Limit(1).Prefix("SELECT EXISTS (").Suffix(")")
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = ur.db.Querier(ctx).QueryRowContext(ctx, sqlStr, args...).Scan(&exists)
+	return exists, err
+}
+
+func (ur *UserRepository) UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	now := time.Now().UTC()
+
+	query := ur.db.Update("users").
+		Set("name", sq.Expr("COALESCE(NULLIF(?, ''), name)", user.Name)).
+		Set("email", sq.Expr("COALESCE(NULLIF(?, ''), email)", user.Email)).
+		Set("password", sq.Expr("COALESCE(NULLIF(?, ''), password)", user.Password)).
+		Set("role", sq.Expr("COALESCE(NULLIF(?, ''), role)", user.Role)).
+		Set("updated_at", now).
+		Where(sq.Eq{"id": user.ID}).
+		Suffix("RETURNING " + userColumns)
+	if user.Password != "" {
+		query = query.Set("password_changed_at", now)
+	}
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	// The replaced hash is copied before the update overwrites it.
+	historyQuery := ur.db.Insert("password_history").
+		Columns("user_id", "password", "created_at").
+		Select(sq.Select("id", "password").Column("?", now).From("users").Where(sq.Eq{"id": user.ID}))
+
+	historySql, historyArgs, err := historyQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	roleQuery := ur.db.Select("role").From("users").Where(sq.Eq{"id": user.ID})
+
+	roleSql, roleArgs, err := roleQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		var oldRole domain.Role
+
+		err := ur.db.Querier(ctx).QueryRowContext(ctx, roleSql, roleArgs...).Scan(&oldRole)
+		if err != nil {
+			return err
+		}
+
+		if user.Password != "" {
+			_, err = ur.db.Querier(ctx).ExecContext(ctx, historySql, historyArgs...)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = ur.db.Querier(ctx).QueryRowContext(ctx, sqlStr, args...).Scan(userFields(user)...)
+		if err != nil {
+			return err
+		}
+
+		event, err := domain.NewUserUpdatedEvent(user)
+		if err != nil {
+			return err
+		}
+		events := []domain.DomainEvent{event}
+
+		if oldRole != user.Role {
+			event, err := domain.NewRoleChangedEvent(user.ID, oldRole, user.Role)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+
+		return appendOutboxEvents(ctx, ur.db, events...)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrorDataNotFound
+		}
+		if errCode := ur.db.ErrorCode(err); errCode == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return nil, domain.ErrorConflictData
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (ur *UserRepository) DeleteUser(ctx context.Context, id uint64) error {
+	query := ur.db.Delete("users").
+		Where(sq.Eq{"id": id}).
+		Suffix("RETURNING " + userColumns)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		var user domain.User
+
+		err := ur.db.Querier(ctx).QueryRowContext(ctx, sqlStr, args...).Scan(userFields(&user)...)
+		if err != nil {
+			return err
+		}
+
+		event, err := domain.NewUserDeletedEvent(&user)
+		if err != nil {
+			return err
+		}
+
+		return appendOutboxEvents(ctx, ur.db, event)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrorDataNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (ur *UserRepository) ListPasswordHistory(ctx context.Context, id uint64, limit uint64) ([]string, error) {
+	var hashes []string
+
+	query := ur.db.Select("password").
+		From("password_history").
+		Where(sq.Eq{"user_id": id}).
+		OrderBy("id DESC").
+		Limit(limit)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ur.db.Querier(ctx).QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+func (ur *UserRepository) RecordLogin(ctx context.Context, event *domain.LoginEvent) (*domain.LoginEvent, error) {
+	now := time.Now().UTC()
+
+	query := ur.db.Insert("login_events").
+		Columns("user_id", "email", "success", "reason", "ip_address", "user_agent", "created_at").
+		Values(sq.Expr("NULLIF(?, 0)", event.UserID), event.Email, event.Success, sq.Expr("NULLIF(?, '')", event.Reason), event.IPAddress, event.UserAgent, now).
+		Suffix("RETURNING id, created_at")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only last_login_at is set, which the user update trigger ignores.
+	lastLoginQuery := ur.db.Update("users").
+		Set("last_login_at", now).
+		Where(sq.Eq{"id": event.UserID})
+
+	lastLoginSql, lastLoginArgs, err := lastLoginQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		err := ur.db.Querier(ctx).QueryRowContext(ctx, sqlStr, args...).Scan(&event.ID, &event.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if !event.Success {
+			return nil
+		}
+
+		result, err := ur.db.Querier(ctx).ExecContext(ctx, lastLoginSql, lastLoginArgs...)
+		if err != nil {
+			return err
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return domain.ErrorDataNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (ur *UserRepository) ListLoginEvents(ctx context.Context, id uint64, skip, limit uint64) ([]domain.LoginEvent, error) {
+	var events []domain.LoginEvent
+
+	query := ur.db.Select(loginEventColumns).
+		From("login_events").
+		OrderBy("id DESC").
+		Limit(limit).
+		Offset((skip - 1) * limit)
+	if id != 0 {
+		query = query.Where(sq.Eq{"user_id": id})
+	}
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ur.db.Querier(ctx).QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event domain.LoginEvent
+		if err := rows.Scan(loginEventFields(&event)...); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (ur *UserRepository) CreateInvitation(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error) {
+	deleteQuery := ur.db.Delete("invitations").Where(sq.Eq{"email": invitation.Email})
+
+	deleteSql, deleteArgs, err := deleteQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := ur.db.Insert("invitations").
+		Columns("email", "role", "token_hash", "invited_by", "expires_at", "created_at").
+		Values(invitation.Email, invitation.Role, invitation.TokenHash, sq.Expr("NULLIF(?, 0)", invitation.InvitedBy), invitation.ExpiresAt.UTC(), time.Now().UTC()).
+		Suffix("RETURNING id, created_at")
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ur.db.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := ur.db.Querier(ctx).ExecContext(ctx, deleteSql, deleteArgs...)
+		if err != nil {
+			return err
+		}
+
+		return ur.db.Querier(ctx).QueryRowContext(ctx, sqlStr, args...).Scan(&invitation.ID, &invitation.CreatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (ur *UserRepository) ClaimInvitation(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	query := ur.db.Delete("invitations").
+		Where(sq.Eq{"token_hash": tokenHash}).
+		Suffix("RETURNING " + invitationColumns)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var invitation domain.Invitation
+
+	err = ur.db.Querier(ctx).QueryRowContext(ctx, sqlStr, args...).Scan(invitationFields(&invitation)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrorDataNotFound
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
@@ -0,0 +1,88 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/sqlite"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/sqlite/repository"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port/porttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDB(t *testing.T) *sqlite.DB {
+	db, err := sqlite.New(context.Background(), config.DB{
+		Connection: "sqlite",
+		Name:       filepath.Join(t.TempDir(), "users.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	require.NoError(t, db.Migrate())
+	return db
+}
+
+func TestUserRepository(t *testing.T) {
+	porttest.TestUserRepository(t, func(t *testing.T) (port.UserRepository, port.Transactor) {
+		db := newDB(t)
+		return repository.NewUserRepository(db), db
+	})
+}
+
+func TestUserEventRepository(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+
+	users := repository.NewUserRepository(db)
+	events := repository.NewUserEventRepository(db)
+
+	user, err := users.CreateUser(ctx, &domain.User{Name: "Ada", Email: "ada@example.com", Password: "secret"})
+	require.NoError(t, err)
+	_, err = users.UpdateUser(ctx, &domain.User{ID: user.ID, Role: domain.Admin})
+	require.NoError(t, err)
+	_, err = users.RecordLogin(ctx, &domain.LoginEvent{UserID: user.ID, Email: user.Email, Success: true})
+	require.NoError(t, err)
+	require.NoError(t, users.DeleteUser(ctx, user.ID))
+
+	last, err := events.LastUserEventSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), last)
+	assert.NoError(t, events.WaitUserEvents(ctx, 2), "Committed event not seen")
+
+	feed, err := events.ListUserEvents(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, feed, 2)
+
+	assert.Equal(t, domain.UserUpdated, feed[0].Type)
+	assert.Equal(t, domain.Admin, feed[0].User.Role)
+	assert.True(t, user.CreatedAt.Equal(feed[0].User.CreatedAt), "Creation time mismatch")
+	assert.Equal(t, domain.UserDeleted, feed[1].Type)
+	assert.Equal(t, user.Email, feed[1].User.Email)
+	assert.Empty(t, feed[1].User.Password, "Password leaked into the feed")
+	assert.True(t, user.PasswordChangedAt.Equal(feed[0].User.PasswordChangedAt), "Password change time mismatch")
+	assert.False(t, feed[1].User.LastLoginAt.IsZero(), "Login time missing")
+
+	pruned, err := events.PruneUserEvents(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), pruned)
+	feed, err = events.ListUserEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, feed, "Pruned events listed")
+
+	outbox := repository.NewOutboxRepository(db)
+
+	claimed, err := outbox.ClaimPendingEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 4)
+	assert.Equal(t, domain.RoleChangedEvent, claimed[2].Type)
+
+	claimed, err = outbox.ClaimPendingEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "Leased events claimed again")
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/migration"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/postgres"
+	pgrepository "github.com/OzkrOssa/radiusx-users/internal/adapter/storage/postgres/repository"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/sqlite"
+	sqliterepository "github.com/OzkrOssa/radiusx-users/internal/adapter/storage/sqlite/repository"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+)
+
+type Storage struct {
+	Users  port.UserRepository
+	Events port.UserEventRepository
+	Outbox port.OutboxRepository
+	Tx     port.Transactor
+
+	ping     func(ctx context.Context) error
+	migrator func() (*migration.Migrator, error)
+	migrate  func() error
+	close    func()
+}
+
+func New(ctx context.Context, config config.DB) (*Storage, error) {
+	switch config.Connection {
+	case "postgres", "postgresql":
+		db, err := postgres.New(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		events := pgrepository.NewUserEventRepository(db)
+		return &Storage{
+			Users:    pgrepository.NewUserRepository(db),
+			Events:   events,
+			Outbox:   pgrepository.NewOutboxRepository(db),
+			Tx:       db,
+			ping:     db.Ping,
+			migrator: db.Migrator,
+			migrate:  db.Migrate,
+			close: func() {
+				events.Close()
+				db.Close()
+			},
+		}, nil
+	case "sqlite":
+		db, err := sqlite.New(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		return &Storage{
+			Users:    sqliterepository.NewUserRepository(db),
+			Events:   sqliterepository.NewUserEventRepository(db),
+			Outbox:   sqliterepository.NewOutboxRepository(db),
+			Tx:       db,
+			ping:     db.PingContext,
+			migrator: db.Migrator,
+			migrate:  db.Migrate,
+			close:    db.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database connection %q", config.Connection)
+	}
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.ping(ctx)
+}
+
+func (s *Storage) Migrate() error {
+	return s.migrate()
+}
+
+// Migrator must be closed after use.
+func (s *Storage) Migrator() (*migration.Migrator, error) {
+	return s.migrator()
+}
+
+func (s *Storage) Close() {
+	s.close()
+}
@@ -0,0 +1,170 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Server reloads the certificate and client CAs when their files change,
+// keeping the previous ones when that fails.
+type Server struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+	logger       log.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+}
+
+func NewServer(config *config.Transport, logger log.Logger) (*Server, error) {
+	s := &Server{
+		certFile:     config.TLSCertFile,
+		keyFile:      config.TLSKeyFile,
+		clientCAFile: config.TLSClientCAFile,
+		clientAuth:   tls.NoClientCert,
+		logger:       logger,
+	}
+
+	if s.clientCAFile != "" {
+		s.clientAuth = tls.RequireAndVerifyClientCert
+		if config.TLSClientAuth == "verify_if_given" {
+			s.clientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Server) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*s.cert},
+				ClientAuth:   s.clientAuth,
+				ClientCAs:    s.clientCAs,
+			}, nil
+		},
+	}
+}
+
+func (s *Server) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := s.Reload()
+			if err != nil {
+				level.Error(s.logger).Log("msg", "failed to reload TLS certificates, keeping the previous ones", "err", err)
+			} else if reloaded {
+				level.Info(s.logger).Log("msg", "reloaded TLS certificates")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Reload reports whether any file changed since the last load.
+func (s *Server) Reload() (bool, error) {
+	files := []string{s.certFile, s.keyFile}
+	if s.clientCAFile != "" {
+		files = append(files, s.clientCAFile)
+	}
+
+	modTimes := make(map[string]time.Time, len(files))
+	changed := false
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		modTimes[file] = info.ModTime()
+
+		s.mu.RLock()
+		if !s.modTimes[file].Equal(info.ModTime()) {
+			changed = true
+		}
+		s.mu.RUnlock()
+	}
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	var clientCAs *x509.CertPool
+	if s.clientCAFile != "" {
+		clientCAs, err = LoadCertPool(s.clientCAFile)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	s.mu.Lock()
+	s.cert, s.clientCAs, s.modTimes = &cert, clientCAs, modTimes
+	s.mu.Unlock()
+
+	return true, nil
+}
+
+// Client verifies against the system roots when caFile is empty.
+func Client(caFile, serverName string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+
+	if caFile != "" {
+		roots, err := LoadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = roots
+	}
+
+	return config, nil
+}
+
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: %w", file, errNoCertificates)
+	}
+
+	return pool, nil
+}
+
+var errNoCertificates = errors.New("no PEM certificates found")
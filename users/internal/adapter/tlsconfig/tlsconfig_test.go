@@ -0,0 +1,171 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/config"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/tlsconfig"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newAuthority(t *testing.T) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &authority{cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for name.
+func (a *authority) issue(t *testing.T, name string, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func write(t *testing.T, path string, data []byte, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// handshake connects a client using clientCert, if any, and returns the serial
+// of the certificate the server presented.
+func handshake(t *testing.T, server *tls.Config, ca *authority, clientCert *tls.Certificate) (int64, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_ = tls.Server(serverConn, server).Handshake()
+		serverConn.Close()
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &tls.Config{RootCAs: roots, ServerName: "users"}
+	if clientCert != nil {
+		client.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	conn := tls.Client(clientConn, client)
+	if err := conn.Handshake(); err != nil {
+		return 0, err
+	}
+	// With TLS 1.3 a rejected client certificate surfaces on the first read.
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !isClosed(err) {
+		return 0, err
+	}
+
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func isClosed(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe)
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	ca := newAuthority(t)
+	modTime := time.Now().Add(-time.Minute)
+
+	transport := &config.Transport{
+		TLSCertFile:     filepath.Join(dir, "tls.crt"),
+		TLSKeyFile:      filepath.Join(dir, "tls.key"),
+		TLSClientCAFile: filepath.Join(dir, "ca.crt"),
+		TLSClientAuth:   "require",
+	}
+
+	cert, key := ca.issue(t, "users", 2, x509.ExtKeyUsageServerAuth)
+	write(t, transport.TLSCertFile, cert, modTime)
+	write(t, transport.TLSKeyFile, key, modTime)
+	write(t, transport.TLSClientCAFile, ca.pem, modTime)
+
+	server, err := tlsconfig.NewServer(transport, log.NewNopLogger())
+	require.NoError(t, err)
+
+	clientPEM, clientKey := ca.issue(t, "radiusxctl", 3, x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKey)
+	require.NoError(t, err)
+
+	serial, err := handshake(t, server.Config(), ca, &clientCert)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), serial)
+
+	_, err = handshake(t, server.Config(), ca, nil)
+	assert.Error(t, err, "Client without certificate accepted")
+
+	reloaded, err := server.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded, "Unchanged files reloaded")
+
+	cert, key = ca.issue(t, "users", 4, x509.ExtKeyUsageServerAuth)
+	write(t, transport.TLSCertFile, cert, modTime.Add(time.Second))
+	write(t, transport.TLSKeyFile, key, modTime.Add(time.Second))
+
+	reloaded, err = server.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	serial, err = handshake(t, server.Config(), ca, &clientCert)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), serial, "Rotated certificate not served")
+
+	write(t, transport.TLSKeyFile, []byte("garbage"), modTime.Add(2*time.Second))
+	_, err = server.Reload()
+	assert.Error(t, err)
+
+	serial, err = handshake(t, server.Config(), ca, &clientCert)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), serial, "Failed reload dropped the previous certificate")
+}
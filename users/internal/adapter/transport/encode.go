@@ -17,14 +17,7 @@ func encodeRegisterResponse(_ context.Context, request interface{}) (response in
 	}
 
 	registerResponse := &usersv1.RegisterResponse{
-		User: &usersv1.User{
-			Id:        req.ID,
-			Name:      req.Name,
-			Email:     req.Email,
-			Role:      usersv1.Role(usersv1.Role_value[string(req.Role)]),
-			CreatedAt: timestamppb.New(req.CreatedAt),
-			UpdatedAt: timestamppb.New(req.UpdatedAt),
-		},
+		User: encodeUser(req),
 	}
 
 	return registerResponse, nil
@@ -38,14 +31,7 @@ func encodeGetUserResponse(_ context.Context, request interface{}) (response int
 	}
 
 	registerResponse := &usersv1.GetUserResponse{
-		User: &usersv1.User{
-			Id:        req.ID,
-			Name:      req.Name,
-			Email:     req.Email,
-			Role:      usersv1.Role(usersv1.Role_value[string(req.Role)]),
-			CreatedAt: timestamppb.New(req.CreatedAt),
-			UpdatedAt: timestamppb.New(req.UpdatedAt),
-		},
+		User: encodeUser(req),
 	}
 
 	return registerResponse, nil
@@ -61,14 +47,7 @@ func encodeListUsersResponse(_ context.Context, request interface{}) (response i
 	var pbUsers []*usersv1.User
 
 	for _, du := range req {
-		u := &usersv1.User{
-			Id:        du.ID,
-			Name:      du.Email,
-			Email:     du.Email,
-			Role:      usersv1.Role(usersv1.Role_value[string(du.Role)]),
-			CreatedAt: timestamppb.New(du.CreatedAt),
-			UpdatedAt: timestamppb.New(du.UpdatedAt),
-		}
+		u := encodeUser(&du)
 
 		pbUsers = append(pbUsers, u)
 	}
@@ -87,21 +66,131 @@ func encodeUpdateUserResponse(_ context.Context, request interface{}) (response
 		return nil, status.Errorf(codes.Internal, "invalid type from endpoint")
 	}
 
-	updateUserResponse := &usersv1.RegisterResponse{
-		User: &usersv1.User{
+	updateUserResponse := &usersv1.UpdateUserResponse{
+		User: encodeUser(req),
+	}
+
+	return updateUserResponse, nil
+
+}
+
+func encodeDeleteUserResponse(_ context.Context, _ interface{}) (response interface{}, err error) {
+	return &usersv1.DeleteUserResponse{}, nil
+}
+
+func encodeWatchUsersResponse(_ context.Context, request interface{}) (response interface{}, err error) {
+	req, ok := request.(*domain.UserEvent)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "invalid type from endpoint")
+	}
+
+	watchUsersResponse := &usersv1.WatchUsersResponse{
+		Sequence:   req.Sequence,
+		Type:       usersv1.UserEventType(usersv1.UserEventType_value[string(req.Type)]),
+		User:       encodeUser(&req.User),
+		OccurredAt: timestamppb.New(req.OccurredAt),
+	}
+
+	return watchUsersResponse, nil
+}
+
+func encodeGetMeResponse(_ context.Context, request interface{}) (response interface{}, err error) {
+	req, ok := request.(*domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "invalid type from endpoint")
+	}
+
+	return &usersv1.GetMeResponse{User: encodeUser(req)}, nil
+}
+
+func encodeUpdateMeResponse(_ context.Context, request interface{}) (response interface{}, err error) {
+	req, ok := request.(*domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "invalid type from endpoint")
+	}
+
+	return &usersv1.UpdateMeResponse{User: encodeUser(req)}, nil
+}
+
+func encodeChangePasswordResponse(_ context.Context, _ interface{}) (response interface{}, err error) {
+	return &usersv1.ChangePasswordResponse{}, nil
+}
+
+func encodeVerifyCredentialsResponse(_ context.Context, request interface{}) (response interface{}, err error) {
+	req, ok := request.(*domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "invalid type from endpoint")
+	}
+
+	return &usersv1.VerifyCredentialsResponse{User: encodeUser(req)}, nil
+}
+
+func encodeListLoginEventsResponse(_ context.Context, request interface{}) (response interface{}, err error) {
+	req, ok := request.([]domain.LoginEvent)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "invalid type from endpoint")
+	}
+
+	var pbEvents []*usersv1.LoginEvent
+
+	for _, event := range req {
+		pbEvents = append(pbEvents, &usersv1.LoginEvent{
+			Id:        event.ID,
+			UserId:    event.UserID,
+			Email:     event.Email,
+			Success:   event.Success,
+			Reason:    usersv1.LoginFailure(usersv1.LoginFailure_value[string(event.Reason)]),
+			IpAddress: event.IPAddress,
+			UserAgent: event.UserAgent,
+			CreatedAt: timestamppb.New(event.CreatedAt),
+		})
+	}
+
+	return &usersv1.ListLoginEventsResponse{LoginEvents: pbEvents}, nil
+}
+
+func encodeInviteUserResponse(_ context.Context, request interface{}) (response interface{}, err error) {
+	req, ok := request.(*domain.Invitation)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "invalid type from endpoint")
+	}
+
+	return &usersv1.InviteUserResponse{
+		Invitation: &usersv1.Invitation{
 			Id:        req.ID,
-			Name:      req.Name,
 			Email:     req.Email,
 			Role:      usersv1.Role(usersv1.Role_value[string(req.Role)]),
+			InvitedBy: req.InvitedBy,
+			ExpiresAt: timestamppb.New(req.ExpiresAt),
 			CreatedAt: timestamppb.New(req.CreatedAt),
-			UpdatedAt: timestamppb.New(req.UpdatedAt),
 		},
-	}
+		Token: req.Token,
+	}, nil
+}
 
-	return updateUserResponse, nil
+func encodeAcceptInvitationResponse(_ context.Context, request interface{}) (response interface{}, err error) {
+	req, ok := request.(*domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "invalid type from endpoint")
+	}
 
+	return &usersv1.AcceptInvitationResponse{User: encodeUser(req)}, nil
 }
 
-func encodeDeleteUserResponse(_ context.Context, _ interface{}) (response interface{}, err error) {
-	return &usersv1.DeleteUserResponse{}, nil
+func encodeUser(user *domain.User) *usersv1.User {
+	pbUser := &usersv1.User{
+		Id:                user.ID,
+		Name:              user.Name,
+		Email:             user.Email,
+		Role:              usersv1.Role(usersv1.Role_value[string(user.Role)]),
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+		UpdatedAt:         timestamppb.New(user.UpdatedAt),
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
+		PasswordExpired:   user.PasswordExpired,
+	}
+	if !user.LastLoginAt.IsZero() {
+		pbUser.LastLoginAt = timestamppb.New(user.LastLoginAt)
+	}
+
+	return pbUser
 }
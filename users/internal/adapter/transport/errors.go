@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/endpoint"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"github.com/bufbuild/protovalidate-go"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// knownErrors without a reason carry no ErrorInfo.
+var knownErrors = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{domain.ErrorConflictData, codes.AlreadyExists, api.ReasonEmailTaken},
+	{domain.ErrorDataNotFound, codes.NotFound, api.ReasonUserNotFound},
+	{domain.ErrorNoUpdatedData, codes.FailedPrecondition, api.ReasonNoChanges},
+	{domain.ErrorInvalidCredentials, codes.Unauthenticated, api.ReasonInvalidCredentials},
+	{domain.ErrorUnauthenticated, codes.Unauthenticated, ""},
+	{domain.ErrorPasswordReused, codes.InvalidArgument, api.ReasonPasswordReused},
+	{domain.ErrorPermissionDenied, codes.PermissionDenied, ""},
+	{domain.ErrorRegistrationClosed, codes.PermissionDenied, api.ReasonRegistrationClosed},
+	{domain.ErrorInvalidInvitation, codes.Unauthenticated, api.ReasonInvalidInvitation},
+	{domain.ErrorInternal, codes.Internal, ""},
+	{endpoint.ErrorLimitExceeded, codes.ResourceExhausted, ""},
+	{api.ErrorIdempotencyKeyReused, codes.InvalidArgument, api.ReasonIdempotencyKeyReused},
+	{api.ErrorIdempotencyKeyInUse, codes.Aborted, api.ReasonIdempotencyKeyInUse},
+}
+
+// encodeError reports anything not recognised as Internal, so no unexpected
+// error text reaches clients.
+func encodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var validationErr *protovalidate.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationStatus(validationErr)
+	}
+
+	for _, e := range knownErrors {
+		if !errors.Is(err, e.err) {
+			continue
+		}
+		st := status.New(e.code, e.err.Error())
+		if e.reason == "" {
+			return st.Err()
+		}
+		return withDetails(st, &errdetails.ErrorInfo{Reason: e.reason, Domain: api.ErrorDomain})
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	return status.Error(codes.Internal, domain.ErrorInternal.Error())
+}
+
+func validationStatus(err *protovalidate.ValidationError) error {
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range err.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       protovalidate.FieldPathString(violation.Proto.GetField()),
+			Description: violation.Proto.GetMessage(),
+		})
+	}
+
+	return withDetails(status.New(codes.InvalidArgument, "invalid request"), badRequest)
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
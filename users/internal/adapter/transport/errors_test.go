@@ -0,0 +1,141 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/endpoint"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/transport"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port/mocks"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newServer(t *testing.T) (usersv1.UserServiceServer, *mocks.UserService) {
+	us := mocks.NewUserService(t)
+	return transport.MakeGrpcTransport(*endpoint.MakeServerEndpoints(us)), us
+}
+
+func details[T any](t *testing.T, err error) []T {
+	st, ok := status.FromError(err)
+	require.True(t, ok, "Not a status error: %v", err)
+
+	var found []T
+	for _, detail := range st.Details() {
+		if d, ok := detail.(T); ok {
+			found = append(found, d)
+		}
+	}
+	return found
+}
+
+func TestTransport_ValidationError(t *testing.T) {
+	server, _ := newServer(t)
+
+	validator, err := transport.NewValidator()
+	require.NoError(t, err)
+	interceptor := transport.ValidationUnaryInterceptor(validator)
+
+	req := &usersv1.RegisterRequest{
+		Name:     "",
+		Email:    "not-an-email",
+		Password: "secretpass1",
+	}
+	_, err = interceptor(context.Background(), req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return server.Register(ctx, req.(*usersv1.RegisterRequest))
+	})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	badRequests := details[*errdetails.BadRequest](t, err)
+	require.Len(t, badRequests, 1)
+
+	fields := map[string]string{}
+	for _, violation := range badRequests[0].FieldViolations {
+		fields[violation.Field] = violation.Description
+	}
+	assert.Len(t, fields, 2)
+	assert.Contains(t, fields, "name")
+	assert.Contains(t, fields, "email")
+}
+
+func TestTransport_DomainErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{
+			name:       "Error_NotFound",
+			err:        domain.ErrorDataNotFound,
+			wantCode:   codes.NotFound,
+			wantReason: api.ReasonUserNotFound,
+		},
+		{
+			name:       "Error_Conflict",
+			err:        domain.ErrorConflictData,
+			wantCode:   codes.AlreadyExists,
+			wantReason: api.ReasonEmailTaken,
+		},
+		{
+			name:       "Error_NoChanges",
+			err:        domain.ErrorNoUpdatedData,
+			wantCode:   codes.FailedPrecondition,
+			wantReason: api.ReasonNoChanges,
+		},
+		{
+			name:       "Error_WrappedNotFound",
+			err:        fmt.Errorf("lookup: %w", domain.ErrorDataNotFound),
+			wantCode:   codes.NotFound,
+			wantReason: api.ReasonUserNotFound,
+		},
+		{
+			name:     "Error_Internal",
+			err:      domain.ErrorInternal,
+			wantCode: codes.Internal,
+		},
+		{
+			name:     "Error_DeadlineExceeded",
+			err:      context.DeadlineExceeded,
+			wantCode: codes.DeadlineExceeded,
+		},
+		{
+			name:     "Error_Unrecognised",
+			err:      errors.New("pq: password authentication failed"),
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, us := newServer(t)
+			us.On("UpdateUser", mock.Anything, mock.Anything).Return(nil, tt.err)
+
+			name := "Ada"
+			_, err := server.UpdateUser(context.Background(), &usersv1.UpdateUserRequest{Id: 1, Name: &name})
+
+			assert.Equal(t, tt.wantCode, status.Code(err), "Code mismatch")
+			assert.NotContains(t, err.Error(), "password authentication", "Cause leaked to the client")
+
+			infos := details[*errdetails.ErrorInfo](t, err)
+			if tt.wantReason == "" {
+				assert.Empty(t, infos)
+				return
+			}
+			require.Len(t, infos, 1)
+			assert.Equal(t, tt.wantReason, infos[0].Reason)
+			assert.Equal(t, api.ErrorDomain, infos[0].Domain)
+		})
+	}
+}
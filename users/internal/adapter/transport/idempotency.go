@@ -0,0 +1,182 @@
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const maxIdempotencyKeyLength = 128
+
+// pendingTTL frees the key of a call that never completes.
+const pendingTTL = time.Minute
+
+var idempotentMethods = map[string]func() proto.Message{
+	usersv1.UserService_Register_FullMethodName:   func() proto.Message { return &usersv1.RegisterResponse{} },
+	usersv1.UserService_UpdateUser_FullMethodName: func() proto.Message { return &usersv1.UpdateUserResponse{} },
+	usersv1.UserService_DeleteUser_FullMethodName: func() proto.Message { return &usersv1.DeleteUserResponse{} },
+}
+
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	// Done is false while the call is being served.
+	Done     bool   `json:"done"`
+	Response []byte `json:"response,omitempty"`
+}
+
+// IdempotencyUnaryInterceptor replays successful responses for window.
+func IdempotencyUnaryInterceptor(cache port.CacheRepository, window time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		newResponse, ok := idempotentMethods[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		values := metadata.ValueFromIncomingContext(ctx, api.IdempotencyKeyHeader)
+		if len(values) == 0 {
+			return handler(ctx, req)
+		}
+		if len(values) > 1 || values[0] == "" || len(values[0]) > maxIdempotencyKeyLength {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s", api.IdempotencyKeyHeader)
+		}
+
+		caller, _ := utils.CallerID(ctx)
+		key := utils.GenerateCacheKeyParams("idempotency", info.FullMethod, caller, values[0])
+
+		fingerprint, err := requestFingerprint(values[0], req)
+		if err != nil {
+			return nil, encodeError(err)
+		}
+
+		claimed, err := claim(ctx, cache, key, fingerprint, min(pendingTTL, window))
+		if err != nil {
+			return nil, claimError(ctx, key, err)
+		}
+		if !claimed {
+			// A record gone since the claim was failed.
+			resp, err := replay(ctx, cache, key, fingerprint, newResponse())
+			if !errors.Is(err, domain.ErrorDataNotFound) {
+				return resp, encodeError(err)
+			}
+			claimed, err = claim(ctx, cache, key, fingerprint, min(pendingTTL, window))
+			if err != nil {
+				return nil, claimError(ctx, key, err)
+			}
+			if !claimed {
+				return nil, encodeError(api.ErrorIdempotencyKeyInUse)
+			}
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			if deleteErr := cache.Delete(ctx, key); deleteErr != nil {
+				logIdempotencyFailure(ctx, key, deleteErr)
+			}
+			return nil, err
+		}
+
+		record := &idempotencyRecord{Fingerprint: fingerprint, Done: true}
+		record.Response, err = proto.Marshal(resp.(proto.Message))
+		if err == nil {
+			err = setRecord(ctx, cache, key, record, window)
+		}
+		if err != nil {
+			logIdempotencyFailure(ctx, key, err)
+		}
+
+		return resp, nil
+	}
+}
+
+func claim(ctx context.Context, cache port.CacheRepository, key, fingerprint string, ttl time.Duration) (bool, error) {
+	pending, err := utils.Serialize(&idempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return false, err
+	}
+	return cache.SetIfAbsent(ctx, key, pending, ttl)
+}
+
+// claimError asks the client to retry, as the call cannot be served without
+// knowing whether it is a duplicate.
+func claimError(ctx context.Context, key string, err error) error {
+	logIdempotencyFailure(ctx, key, err)
+	return status.Error(codes.Unavailable, "idempotency key cannot be checked")
+}
+
+func replay(ctx context.Context, cache port.CacheRepository, key, fingerprint string, resp proto.Message) (interface{}, error) {
+	record, err := getRecord(ctx, cache, key)
+	switch {
+	case err != nil:
+		return nil, err
+	case record.Fingerprint != fingerprint:
+		return nil, api.ErrorIdempotencyKeyReused
+	case !record.Done:
+		return nil, api.ErrorIdempotencyKeyInUse
+	}
+
+	if err := proto.Unmarshal(record.Response, resp); err != nil {
+		return nil, err
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(api.IdempotentReplayHeader, "true"))
+	return resp, nil
+}
+
+// requestFingerprint salts the hash with the key, as requests may carry a
+// password.
+func requestFingerprint(key string, req interface{}) (string, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return "", domain.ErrorInternal
+	}
+
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(key))
+	hash.Write([]byte{0})
+	hash.Write(payload)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func getRecord(ctx context.Context, cache port.CacheRepository, key string) (*idempotencyRecord, error) {
+	value, err := cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &idempotencyRecord{}
+	if err := utils.Deserialize(value, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func setRecord(ctx context.Context, cache port.CacheRepository, key string, record *idempotencyRecord, ttl time.Duration) error {
+	value, err := utils.Serialize(record)
+	if err != nil {
+		return err
+	}
+	return cache.Set(ctx, key, value, ttl)
+}
+
+func logIdempotencyFailure(ctx context.Context, key string, err error) {
+	level.Warn(utils.Logger(ctx, log.NewNopLogger())).Log("msg", "idempotency record not updated", "key", key, "err", err)
+}
@@ -0,0 +1,218 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/memory"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/transport"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port/mocks"
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var registerInfo = &grpc.UnaryServerInfo{FullMethod: usersv1.UserService_Register_FullMethodName}
+
+func withIdempotencyKey(ctx context.Context, keys ...string) context.Context {
+	md := metadata.MD{}
+	for _, key := range keys {
+		md.Append(api.IdempotencyKeyHeader, key)
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// countingHandler registers a user with a new id on every call.
+type countingHandler struct {
+	calls uint64
+	err   error
+}
+
+func (h *countingHandler) handle(context.Context, interface{}) (interface{}, error) {
+	h.calls++
+	if h.err != nil {
+		return nil, h.err
+	}
+	return &usersv1.RegisterResponse{User: &usersv1.User{Id: h.calls, Name: "Ada"}}, nil
+}
+
+func TestIdempotencyUnaryInterceptor(t *testing.T) {
+	request := &usersv1.RegisterRequest{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"}
+	other := &usersv1.RegisterRequest{Name: "Grace", Email: "grace@example.com", Password: "secretpass2"}
+
+	tests := []struct {
+		name      string
+		first     context.Context
+		second    context.Context
+		info      *grpc.UnaryServerInfo
+		request   proto.Message
+		wantCode  codes.Code
+		wantCalls uint64
+	}{
+		{
+			name:      "Success_Replayed",
+			first:     withIdempotencyKey(context.Background(), "key-1"),
+			second:    withIdempotencyKey(context.Background(), "key-1"),
+			request:   request,
+			wantCalls: 1,
+		},
+		{
+			name:      "Success_OtherKey",
+			first:     withIdempotencyKey(context.Background(), "key-1"),
+			second:    withIdempotencyKey(context.Background(), "key-2"),
+			request:   request,
+			wantCalls: 2,
+		},
+		{
+			name:      "Success_NoKey",
+			first:     context.Background(),
+			second:    context.Background(),
+			request:   request,
+			wantCalls: 2,
+		},
+		{
+			name:      "Success_OtherCaller",
+			first:     withIdempotencyKey(utils.WithCallerID(context.Background(), 1), "key-1"),
+			second:    withIdempotencyKey(utils.WithCallerID(context.Background(), 2), "key-1"),
+			request:   request,
+			wantCalls: 2,
+		},
+		{
+			name:      "Success_MethodWithoutKeys",
+			first:     withIdempotencyKey(context.Background(), "key-1"),
+			second:    withIdempotencyKey(context.Background(), "key-1"),
+			info:      &grpc.UnaryServerInfo{FullMethod: usersv1.UserService_GetUser_FullMethodName},
+			request:   request,
+			wantCalls: 2,
+		},
+		{
+			name:      "Error_KeyReused",
+			first:     withIdempotencyKey(context.Background(), "key-1"),
+			second:    withIdempotencyKey(context.Background(), "key-1"),
+			request:   other,
+			wantCode:  codes.InvalidArgument,
+			wantCalls: 1,
+		},
+		{
+			name:      "Error_RepeatedKey",
+			first:     withIdempotencyKey(context.Background(), "key-1"),
+			second:    withIdempotencyKey(context.Background(), "key-1", "key-2"),
+			request:   request,
+			wantCode:  codes.InvalidArgument,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := transport.IdempotencyUnaryInterceptor(memory.New(100), time.Hour)
+			handler := &countingHandler{}
+			info := tt.info
+			if info == nil {
+				info = registerInfo
+			}
+
+			first, err := interceptor(tt.first, request, info, handler.handle)
+			require.NoError(t, err)
+
+			second, err := interceptor(tt.second, tt.request, info, handler.handle)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantCalls, handler.calls, "Handler calls mismatch")
+			if err == nil && tt.wantCalls == 1 {
+				assert.True(t, proto.Equal(first.(proto.Message), second.(proto.Message)), "Response not replayed")
+			}
+		})
+	}
+}
+
+func TestIdempotencyUnaryInterceptor_FailedCallRetried(t *testing.T) {
+	interceptor := transport.IdempotencyUnaryInterceptor(memory.New(100), time.Hour)
+	ctx := withIdempotencyKey(context.Background(), "key-1")
+	request := &usersv1.RegisterRequest{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"}
+	handler := &countingHandler{err: status.Error(codes.Unavailable, "try again")}
+
+	_, err := interceptor(ctx, request, registerInfo, handler.handle)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	handler.err = nil
+	resp, err := interceptor(ctx, request, registerInfo, handler.handle)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), resp.(*usersv1.RegisterResponse).User.Id, "Failed call kept")
+}
+
+func TestIdempotencyUnaryInterceptor_CacheUnavailable(t *testing.T) {
+	cache := mocks.NewCacheRepository(t)
+	cache.On("SetIfAbsent", mock.Anything, mock.Anything, mock.Anything, time.Minute).Return(false, errors.New("connection refused"))
+
+	interceptor := transport.IdempotencyUnaryInterceptor(cache, time.Hour)
+	ctx := withIdempotencyKey(context.Background(), "key-1")
+	request := &usersv1.RegisterRequest{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"}
+	handler := &countingHandler{}
+
+	_, err := interceptor(ctx, request, registerInfo, handler.handle)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Zero(t, handler.calls, "Call served without an idempotency check")
+}
+
+func TestIdempotencyUnaryInterceptor_InUse(t *testing.T) {
+	interceptor := transport.IdempotencyUnaryInterceptor(memory.New(100), time.Hour)
+	ctx := withIdempotencyKey(context.Background(), "key-1")
+	request := &usersv1.RegisterRequest{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"}
+
+	var concurrent error
+	_, err := interceptor(ctx, request, registerInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, concurrent = interceptor(ctx, req, registerInfo, (&countingHandler{}).handle)
+		return &usersv1.RegisterResponse{User: &usersv1.User{Id: 1}}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, codes.Aborted, status.Code(concurrent), "Call served while the first was in progress")
+}
+
+func TestIdempotencyUnaryInterceptor_ConcurrentDuplicates(t *testing.T) {
+	interceptor := transport.IdempotencyUnaryInterceptor(memory.New(100), time.Hour)
+	ctx := withIdempotencyKey(context.Background(), "key-1")
+	request := &usersv1.RegisterRequest{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		calls.Add(1)
+		<-release
+		return &usersv1.RegisterResponse{User: &usersv1.User{Id: 1}}, nil
+	}
+
+	const duplicates = 8
+	var wg sync.WaitGroup
+	codesSeen := make(chan codes.Code, duplicates)
+	for range duplicates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := interceptor(ctx, request, registerInfo, handler)
+			codesSeen <- status.Code(err)
+		}()
+	}
+
+	// Every duplicate but the one being served is turned down before the
+	// first completes.
+	for range duplicates - 1 {
+		assert.Equal(t, codes.Aborted, <-codesSeen)
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, codes.OK, <-codesSeen)
+	assert.Equal(t, int32(1), calls.Load(), "Handler ran for a duplicate")
+}
@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// IdentityUnaryInterceptor trusts api.UserIDHeader as set by the gateway, so
+// it must only be installed when callers are known to be the gateway. Calls
+// without the header go through anonymously.
+func IdentityUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := withCaller(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func IdentityStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := withCaller(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func withCaller(ctx context.Context) (context.Context, error) {
+	values := metadata.ValueFromIncomingContext(ctx, api.UserIDHeader)
+	if len(values) == 0 {
+		return ctx, nil
+	}
+
+	id, err := strconv.ParseUint(values[0], 10, 64)
+	if len(values) > 1 || err != nil || id == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid %s", api.UserIDHeader)
+	}
+
+	return utils.WithCallerID(ctx, id), nil
+}
@@ -0,0 +1,52 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/transport"
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestIdentityUnaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name       string
+		userIDs    []string
+		wantCaller bool
+		wantID     uint64
+		wantCode   codes.Code
+	}{
+		{name: "Success_Caller", userIDs: []string{"42"}, wantCaller: true, wantID: 42},
+		{name: "Success_Anonymous"},
+		{name: "Error_NotANumber", userIDs: []string{"admin"}, wantCode: codes.Unauthenticated},
+		{name: "Error_Zero", userIDs: []string{"0"}, wantCode: codes.Unauthenticated},
+		{name: "Error_Repeated", userIDs: []string{"42", "43"}, wantCode: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.MD{}
+			for _, id := range tt.userIDs {
+				md.Append(api.UserIDHeader, id)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			var gotID uint64
+			var gotCaller bool
+			_, err := transport.IdentityUnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+				gotID, gotCaller = utils.CallerID(ctx)
+				return nil, nil
+			})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantCaller, gotCaller)
+			assert.Equal(t, tt.wantID, gotID)
+		})
+	}
+}
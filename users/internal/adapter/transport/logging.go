@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// healthMethodPrefix calls are logged at debug level.
+const healthMethodPrefix = "/grpc.health.v1.Health/"
+
+const maxRequestIDLength = 128
+
+// UnaryServerInterceptor puts the request id and a logger carrying it in the
+// context.
+func UnaryServerInterceptor(logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		ctx, requestLogger, id := newRequestContext(ctx, logger)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(api.RequestIDHeader, id))
+
+		resp, err := handler(ctx, req)
+
+		logCall(requestLogger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func StreamServerInterceptor(logger log.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		ctx, requestLogger, id := newRequestContext(ss.Context(), logger)
+		_ = ss.SetHeader(metadata.Pairs(api.RequestIDHeader, id))
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+
+		logCall(requestLogger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func newRequestContext(ctx context.Context, logger log.Logger) (context.Context, log.Logger, string) {
+	id := incomingRequestID(ctx)
+	if id == "" {
+		id = newRequestID()
+	}
+
+	logger = log.With(logger, "request_id", id)
+
+	ctx = utils.WithRequestID(ctx, id)
+	ctx = utils.WithLogger(ctx, logger)
+	return ctx, logger, id
+}
+
+// incomingRequestID ignores ids that would garble the logs.
+func incomingRequestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(api.RequestIDHeader)
+	if len(values) == 0 {
+		return ""
+	}
+
+	id := values[0]
+	if len(id) > maxRequestIDLength {
+		return ""
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return ""
+		}
+	}
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func logCall(logger log.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+
+	keyvals := []interface{}{"method", method, "code", code, "duration", time.Since(start)}
+	if err != nil {
+		keyvals = append(keyvals, "err", err)
+	}
+
+	switch {
+	case strings.HasPrefix(method, healthMethodPrefix):
+		level.Debug(logger).Log(keyvals...)
+	case code == codes.Internal, code == codes.Unknown, code == codes.DataLoss, code == codes.Unavailable:
+		level.Error(logger).Log(keyvals...)
+	default:
+		level.Info(logger).Log(keyvals...)
+	}
+}
@@ -0,0 +1,87 @@
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/transport"
+	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		handleErr error
+		wantID    *regexp.Regexp
+		wantLog   []string
+	}{
+		{
+			name:      "Success_CallerRequestID",
+			requestID: "abc-123",
+			wantID:    regexp.MustCompile(`^abc-123$`),
+			wantLog:   []string{"level=info", "request_id=abc-123", "method=/users.v1.UserService/GetUser", "code=OK"},
+		},
+		{
+			name:    "Success_GeneratedRequestID",
+			wantID:  regexp.MustCompile(`^[0-9a-f]{32}$`),
+			wantLog: []string{"level=info", "code=OK"},
+		},
+		{
+			name:      "Success_RejectsUnprintableRequestID",
+			requestID: "bad id\n",
+			wantID:    regexp.MustCompile(`^[0-9a-f]{32}$`),
+		},
+		{
+			name:      "Error_InternalLoggedAsError",
+			requestID: "abc-123",
+			handleErr: status.Error(codes.Internal, "internal server error"),
+			wantID:    regexp.MustCompile(`^abc-123$`),
+			wantLog:   []string{"level=error", "code=Internal", `err="rpc error: code = Internal desc = internal server error"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			interceptor := transport.UnaryServerInterceptor(log.NewLogfmtLogger(&logs))
+
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(api.RequestIDHeader, tt.requestID))
+			}
+
+			var handlerID string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				handlerID = utils.RequestID(ctx)
+				level.Info(utils.Logger(ctx, log.NewNopLogger())).Log("msg", "from handler")
+				return nil, tt.handleErr
+			}
+
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/users.v1.UserService/GetUser"}, handler)
+			assert.Equal(t, tt.handleErr, err, "Error mismatch")
+
+			assert.Regexp(t, tt.wantID, handlerID)
+
+			lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+			require.Len(t, lines, 2)
+			assert.Contains(t, lines[0], "request_id="+handlerID, "Handler logger lacks the request id")
+			assert.Contains(t, lines[1], "request_id="+handlerID)
+			for _, want := range tt.wantLog {
+				assert.Contains(t, lines[1], want)
+			}
+		})
+	}
+}
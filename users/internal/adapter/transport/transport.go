@@ -6,106 +6,166 @@ import (
 	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
 	"github.com/OzkrOssa/radiusx-users/internal/adapter/endpoint"
 	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	kitendpoint "github.com/go-kit/kit/endpoint"
 	gt "github.com/go-kit/kit/transport/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type grpcTransport struct {
-	RegisterHandler   gt.Handler
-	GetUserHandler    gt.Handler
-	ListUsersHandler  gt.Handler
-	UpdateUserHandler gt.Handler
-	DeleteUserHandler gt.Handler
+	RegisterHandler          gt.Handler
+	GetUserHandler           gt.Handler
+	ListUsersHandler         gt.Handler
+	UpdateUserHandler        gt.Handler
+	DeleteUserHandler        gt.Handler
+	GetMeHandler             gt.Handler
+	UpdateMeHandler          gt.Handler
+	ChangePasswordHandler    gt.Handler
+	VerifyCredentialsHandler gt.Handler
+	ListLoginEventsHandler   gt.Handler
+	InviteUserHandler        gt.Handler
+	AcceptInvitationHandler  gt.Handler
+	// go-kit's gRPC transport has no streaming support, so the stream is
+	// served against the endpoint directly.
+	WatchUsersEndpoint kitendpoint.Endpoint
 	usersv1.UnimplementedUserServiceServer
 }
 
 func MakeGrpcTransport(endpoint endpoint.Endpoints) usersv1.UserServiceServer {
 	return &grpcTransport{
-		RegisterHandler:   gt.NewServer(endpoint.RegisterEndopoint, decodeRegisterRequest, encodeRegisterResponse),
-		GetUserHandler:    gt.NewServer(endpoint.GetUserEndopoint, decodeGetUserRequest, encodeGetUserResponse),
-		ListUsersHandler:  gt.NewServer(endpoint.ListUsersEndopoint, decodeListUsersRequest, encodeListUsersResponse),
-		UpdateUserHandler: gt.NewServer(endpoint.UpdateUserEndopoint, decodeUpdateUserRequest, encodeUpdateUserResponse),
+		RegisterHandler:          gt.NewServer(endpoint.RegisterEndopoint, decodeRequest[*usersv1.RegisterRequest], encodeRegisterResponse),
+		GetUserHandler:           gt.NewServer(endpoint.GetUserEndopoint, decodeRequest[*usersv1.GetUserRequest], encodeGetUserResponse),
+		ListUsersHandler:         gt.NewServer(endpoint.ListUsersEndopoint, decodeRequest[*usersv1.ListUsersRequest], encodeListUsersResponse),
+		UpdateUserHandler:        gt.NewServer(endpoint.UpdateUserEndopoint, decodeRequest[*usersv1.UpdateUserRequest], encodeUpdateUserResponse),
+		DeleteUserHandler:        gt.NewServer(endpoint.DeleteUserEndopoint, decodeRequest[*usersv1.DeleteUserRequest], encodeDeleteUserResponse),
+		WatchUsersEndpoint:       endpoint.WatchUsersEndopoint,
+		GetMeHandler:             gt.NewServer(endpoint.GetMeEndopoint, decodeRequest[*usersv1.GetMeRequest], encodeGetMeResponse),
+		UpdateMeHandler:          gt.NewServer(endpoint.UpdateMeEndopoint, decodeRequest[*usersv1.UpdateMeRequest], encodeUpdateMeResponse),
+		ChangePasswordHandler:    gt.NewServer(endpoint.ChangePasswordEndopoint, decodeRequest[*usersv1.ChangePasswordRequest], encodeChangePasswordResponse),
+		VerifyCredentialsHandler: gt.NewServer(endpoint.VerifyCredentialsEndopoint, decodeRequest[*usersv1.VerifyCredentialsRequest], encodeVerifyCredentialsResponse),
+		ListLoginEventsHandler:   gt.NewServer(endpoint.ListLoginEventsEndopoint, decodeRequest[*usersv1.ListLoginEventsRequest], encodeListLoginEventsResponse),
+		InviteUserHandler:        gt.NewServer(endpoint.InviteUserEndopoint, decodeRequest[*usersv1.InviteUserRequest], encodeInviteUserResponse),
+		AcceptInvitationHandler:  gt.NewServer(endpoint.AcceptInvitationEndopoint, decodeRequest[*usersv1.AcceptInvitationRequest], encodeAcceptInvitationResponse),
 	}
 }
 
 func (g *grpcTransport) Register(ctx context.Context, request *usersv1.RegisterRequest) (*usersv1.RegisterResponse, error) {
-
 	_, resp, err := g.RegisterHandler.ServeGRPC(ctx, request)
 	if err != nil {
-		switch err {
-		case domain.ErrorConflictData:
-			return nil, status.Errorf(codes.AlreadyExists, err.Error())
-		case domain.ErrorInternal:
-			return nil, status.Errorf(codes.Internal, err.Error())
-		default:
-			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
-		}
+		return nil, encodeError(err)
 	}
 
 	return resp.(*usersv1.RegisterResponse), nil
 }
+
 func (g *grpcTransport) GetUser(ctx context.Context, request *usersv1.GetUserRequest) (*usersv1.GetUserResponse, error) {
 	_, resp, err := g.GetUserHandler.ServeGRPC(ctx, request)
-
 	if err != nil {
-		switch err {
-		case domain.ErrorDataNotFound:
-			return nil, status.Errorf(codes.NotFound, err.Error())
-		case domain.ErrorInternal:
-			return nil, status.Errorf(codes.Internal, err.Error())
-		default:
-			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
-		}
+		return nil, encodeError(err)
 	}
 
 	return resp.(*usersv1.GetUserResponse), nil
-
 }
+
 func (g *grpcTransport) ListUsers(ctx context.Context, request *usersv1.ListUsersRequest) (*usersv1.ListUsersResponse, error) {
 	_, resp, err := g.ListUsersHandler.ServeGRPC(ctx, request)
-
 	if err != nil {
-		switch err {
-		case domain.ErrorInternal:
-			return nil, status.Errorf(codes.Internal, err.Error())
-		default:
-			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
-		}
+		return nil, encodeError(err)
 	}
 
 	return resp.(*usersv1.ListUsersResponse), nil
 }
+
 func (g *grpcTransport) UpdateUser(ctx context.Context, request *usersv1.UpdateUserRequest) (*usersv1.UpdateUserResponse, error) {
 	_, resp, err := g.UpdateUserHandler.ServeGRPC(ctx, request)
-
 	if err != nil {
-		switch err {
-		case domain.ErrorDataNotFound:
-			return nil, status.Errorf(codes.NotFound, err.Error())
-		case domain.ErrorNoUpdatedData:
-			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
-		case domain.ErrorInternal:
-			return nil, status.Errorf(codes.Internal, err.Error())
-		default:
-			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
-		}
+		return nil, encodeError(err)
 	}
 
 	return resp.(*usersv1.UpdateUserResponse), nil
 }
+
 func (g *grpcTransport) DeleteUser(ctx context.Context, request *usersv1.DeleteUserRequest) (*usersv1.DeleteUserResponse, error) {
 	_, resp, err := g.DeleteUserHandler.ServeGRPC(ctx, request)
 	if err != nil {
-		switch err {
-		case domain.ErrorDataNotFound:
-			return nil, status.Errorf(codes.NotFound, err.Error())
-		case domain.ErrorInternal:
-			return nil, status.Errorf(codes.Internal, err.Error())
-		default:
-			return nil, status.Errorf(codes.InvalidArgument, err.Error())
-		}
+		return nil, encodeError(err)
 	}
 
 	return resp.(*usersv1.DeleteUserResponse), nil
 }
+
+func (g *grpcTransport) WatchUsers(request *usersv1.WatchUsersRequest, stream usersv1.UserService_WatchUsersServer) error {
+	ctx := stream.Context()
+
+	send := func(event *domain.UserEvent) error {
+		resp, err := encodeWatchUsersResponse(ctx, event)
+		if err != nil {
+			return err
+		}
+		return stream.Send(resp.(*usersv1.WatchUsersResponse))
+	}
+
+	_, err := g.WatchUsersEndpoint(ctx, &endpoint.WatchUsersRequest{Since: request.AfterSequence, Send: send})
+	return encodeError(err)
+}
+
+func (g *grpcTransport) GetMe(ctx context.Context, request *usersv1.GetMeRequest) (*usersv1.GetMeResponse, error) {
+	_, resp, err := g.GetMeHandler.ServeGRPC(ctx, request)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return resp.(*usersv1.GetMeResponse), nil
+}
+
+func (g *grpcTransport) UpdateMe(ctx context.Context, request *usersv1.UpdateMeRequest) (*usersv1.UpdateMeResponse, error) {
+	_, resp, err := g.UpdateMeHandler.ServeGRPC(ctx, request)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return resp.(*usersv1.UpdateMeResponse), nil
+}
+
+func (g *grpcTransport) ChangePassword(ctx context.Context, request *usersv1.ChangePasswordRequest) (*usersv1.ChangePasswordResponse, error) {
+	_, resp, err := g.ChangePasswordHandler.ServeGRPC(ctx, request)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return resp.(*usersv1.ChangePasswordResponse), nil
+}
+
+func (g *grpcTransport) VerifyCredentials(ctx context.Context, request *usersv1.VerifyCredentialsRequest) (*usersv1.VerifyCredentialsResponse, error) {
+	_, resp, err := g.VerifyCredentialsHandler.ServeGRPC(ctx, request)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return resp.(*usersv1.VerifyCredentialsResponse), nil
+}
+
+func (g *grpcTransport) ListLoginEvents(ctx context.Context, request *usersv1.ListLoginEventsRequest) (*usersv1.ListLoginEventsResponse, error) {
+	_, resp, err := g.ListLoginEventsHandler.ServeGRPC(ctx, request)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return resp.(*usersv1.ListLoginEventsResponse), nil
+}
+
+func (g *grpcTransport) InviteUser(ctx context.Context, request *usersv1.InviteUserRequest) (*usersv1.InviteUserResponse, error) {
+	_, resp, err := g.InviteUserHandler.ServeGRPC(ctx, request)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return resp.(*usersv1.InviteUserResponse), nil
+}
+
+func (g *grpcTransport) AcceptInvitation(ctx context.Context, request *usersv1.AcceptInvitationRequest) (*usersv1.AcceptInvitationResponse, error) {
+	_, resp, err := g.AcceptInvitationHandler.ServeGRPC(ctx, request)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return resp.(*usersv1.AcceptInvitationResponse), nil
+}
@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"context"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/bufbuild/protovalidate-go"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NewValidator compiles the users.v1 constraints up front.
+func NewValidator() (*protovalidate.Validator, error) {
+	messages := usersv1.File_users_v1_users_proto.Messages()
+
+	descriptors := make([]protoreflect.MessageDescriptor, 0, messages.Len())
+	for i := range messages.Len() {
+		descriptors = append(descriptors, messages.Get(i))
+	}
+
+	return protovalidate.New(protovalidate.WithDescriptors(descriptors...))
+}
+
+func ValidationUnaryInterceptor(validator *protovalidate.Validator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := validate(validator, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func ValidationStreamInterceptor(validator *protovalidate.Validator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: ss, validator: validator})
+	}
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+	validator *protovalidate.Validator
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validate(s.validator, m)
+}
+
+func validate(validator *protovalidate.Validator, req interface{}) error {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	return encodeError(validator.Validate(msg))
+}
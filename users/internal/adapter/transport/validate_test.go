@@ -0,0 +1,93 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/transport"
+	"github.com/bufbuild/protovalidate-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// recvStream receives msg once.
+type recvStream struct {
+	grpc.ServerStream
+	msg proto.Message
+}
+
+func (s *recvStream) Context() context.Context { return context.Background() }
+
+func (s *recvStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.msg)
+	return nil
+}
+
+func TestValidationStreamInterceptor(t *testing.T) {
+	validator, err := transport.NewValidator()
+	require.NoError(t, err)
+	interceptor := transport.ValidationStreamInterceptor(validator)
+
+	tests := []struct {
+		name     string
+		msg      proto.Message
+		wantCode codes.Code
+	}{
+		{name: "Success_Valid", msg: &usersv1.ListUsersRequest{Skip: 1, Limit: 10}, wantCode: codes.OK},
+		{name: "Error_Invalid", msg: &usersv1.ListUsersRequest{Skip: 0, Limit: 10}, wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := interceptor(nil, &recvStream{msg: tt.msg}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+				return stream.RecvMsg(&usersv1.ListUsersRequest{})
+			})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+var benchmarkRequest = &usersv1.RegisterRequest{
+	Name:     "Ada Lovelace",
+	Email:    "ada@example.com",
+	Password: "secretpass1",
+}
+
+// BenchmarkValidate compares building a validator for every request, as the
+// decoders used to, against the validator shared by the interceptor.
+func BenchmarkValidate(b *testing.B) {
+	b.Run("PerRequestValidator", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			validator, err := protovalidate.New(protovalidate.WithMessages(&usersv1.RegisterRequest{}))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := validator.Validate(benchmarkRequest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("SharedValidator", func(b *testing.B) {
+		validator, err := transport.NewValidator()
+		if err != nil {
+			b.Fatal(err)
+		}
+		interceptor := transport.ValidationUnaryInterceptor(validator)
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for range b.N {
+			if _, err := interceptor(context.Background(), benchmarkRequest, &grpc.UnaryServerInfo{}, handler); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package domain
+
+type InvalidationKind string
+
+const (
+	InvalidateKey InvalidationKind = "key"
+	// InvalidatePrefix takes a glob pattern.
+	InvalidatePrefix     InvalidationKind = "prefix"
+	InvalidateGeneration InvalidationKind = "generation"
+)
+
+// Invalidation carries its Origin so publishers can skip their own.
+type Invalidation struct {
+	Kind   InvalidationKind
+	Key    string
+	Origin string
+}
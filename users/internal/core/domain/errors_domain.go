@@ -3,8 +3,14 @@ package domain
 import "errors"
 
 var (
-	ErrorDataNotFound  = errors.New("data not found")
-	ErrorConflictData  = errors.New("data conflicts with existing data")
-	ErrorNoUpdatedData = errors.New("no data to update")
-	ErrorInternal      = errors.New("internal server error")
+	ErrorDataNotFound       = errors.New("data not found")
+	ErrorConflictData       = errors.New("data conflicts with existing data")
+	ErrorNoUpdatedData      = errors.New("no data to update")
+	ErrorInternal           = errors.New("internal server error")
+	ErrorUnauthenticated    = errors.New("caller not authenticated")
+	ErrorInvalidCredentials = errors.New("invalid credentials")
+	ErrorPasswordReused     = errors.New("password used recently")
+	ErrorPermissionDenied   = errors.New("permission denied")
+	ErrorRegistrationClosed = errors.New("registration is by invitation only")
+	ErrorInvalidInvitation  = errors.New("invitation invalid or expired")
 )
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type UserEventType string
+
+const (
+	UserCreated UserEventType = "USER_EVENT_TYPE_CREATED"
+	UserUpdated UserEventType = "USER_EVENT_TYPE_UPDATED"
+	UserDeleted UserEventType = "USER_EVENT_TYPE_DELETED"
+)
+
+type UserEvent struct {
+	Sequence   uint64
+	Type       UserEventType
+	User       User
+	OccurredAt time.Time
+}
+
+type DomainEventType string
+
+const (
+	UserRegisteredEvent DomainEventType = "UserRegistered"
+	UserUpdatedEvent    DomainEventType = "UserUpdated"
+	UserDeletedEvent    DomainEventType = "UserDeleted"
+	RoleChangedEvent    DomainEventType = "RoleChanged"
+)
+
+// DomainEvent Payload is the JSON document consumers receive.
+type DomainEvent struct {
+	ID         uint64
+	Type       DomainEventType
+	UserID     uint64
+	Payload    []byte
+	Attempts   int
+	OccurredAt time.Time
+}
+
+type userEventPayload struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type roleChangedPayload struct {
+	UserID  uint64 `json:"user_id"`
+	OldRole Role   `json:"old_role"`
+	NewRole Role   `json:"new_role"`
+}
+
+func NewUserRegisteredEvent(user *User) (DomainEvent, error) {
+	return newUserEvent(UserRegisteredEvent, user)
+}
+
+func NewUserUpdatedEvent(user *User) (DomainEvent, error) {
+	return newUserEvent(UserUpdatedEvent, user)
+}
+
+func NewUserDeletedEvent(user *User) (DomainEvent, error) {
+	return newUserEvent(UserDeletedEvent, user)
+}
+
+func NewRoleChangedEvent(userID uint64, oldRole, newRole Role) (DomainEvent, error) {
+	payload, err := json.Marshal(roleChangedPayload{UserID: userID, OldRole: oldRole, NewRole: newRole})
+	if err != nil {
+		return DomainEvent{}, err
+	}
+	return DomainEvent{Type: RoleChangedEvent, UserID: userID, Payload: payload}, nil
+}
+
+func newUserEvent(eventType DomainEventType, user *User) (DomainEvent, error) {
+	payload, err := json.Marshal(userEventPayload{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	})
+	if err != nil {
+		return DomainEvent{}, err
+	}
+	return DomainEvent{Type: eventType, UserID: user.ID, Payload: payload}, nil
+}
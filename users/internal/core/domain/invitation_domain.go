@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+type Invitation struct {
+	ID        uint64
+	Email     string
+	Role      Role
+	TokenHash string
+	// Token is never stored, and only set on the invitation just created.
+	Token     string `json:"-"`
+	InvitedBy uint64
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type RegistrationPolicy struct {
+	InviteOnly    bool
+	InvitationTTL time.Duration
+}
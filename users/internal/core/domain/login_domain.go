@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+type LoginFailure string
+
+const (
+	LoginFailureUnknownEmail  LoginFailure = "LOGIN_FAILURE_UNKNOWN_EMAIL"
+	LoginFailureWrongPassword LoginFailure = "LOGIN_FAILURE_WRONG_PASSWORD"
+)
+
+// LoginOrigin is as seen by the service that received the attempt.
+type LoginOrigin struct {
+	IPAddress string
+	UserAgent string
+}
+
+type LoginEvent struct {
+	ID uint64
+	// UserID is zero when Email belongs to no user.
+	UserID  uint64
+	Email   string
+	Success bool
+	// Reason is empty for successful attempts.
+	Reason LoginFailure
+	LoginOrigin
+	CreatedAt time.Time
+}
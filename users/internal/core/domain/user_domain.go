@@ -2,20 +2,41 @@ package domain
 
 import "time"
 
+// Role values are the names of the users_role_enum column type and of the
+// users.v1.Role enum, so they are stored and encoded as they are.
 type Role string
 
 const (
-	Reader Role = "reader"
-	Agent  Role = "agent"
-	Admin  Role = "admin"
+	Reader Role = "ROLE_READER"
+	Agent  Role = "ROLE_AGENT"
+	Admin  Role = "ROLE_ADMIN"
 )
 
 type User struct {
-	ID        uint64
-	Name      string
-	Email     string
-	Password  string
-	Role      Role
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID                uint64
+	Name              string
+	Email             string
+	Password          string
+	Role              Role
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	PasswordChangedAt time.Time
+	LastLoginAt       time.Time
+	// PasswordExpired is never stored.
+	PasswordExpired bool `json:"-"`
+}
+
+type PasswordPolicy struct {
+	// HistorySize counts the current password; zero allows any.
+	HistorySize int
+	// MaxAge leaves roles without one to never expire.
+	MaxAge map[Role]time.Duration
+}
+
+func (p PasswordPolicy) Expired(user *User, now time.Time) bool {
+	maxAge := p.MaxAge[user.Role]
+	if maxAge <= 0 || user.PasswordChangedAt.IsZero() {
+		return false
+	}
+	return now.Sub(user.PasswordChangedAt) > maxAge
 }
@@ -3,12 +3,24 @@ package port
 import (
 	"context"
 	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
 )
 
 type CacheRepository interface {
 	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetIfAbsent is atomic.
+	SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
 	Get(ctx context.Context, key string) ([]byte, error)
 	Delete(ctx context.Context, key string) error
 	DeleteByPrefix(ctx context.Context, prefix string) error
+	Increment(ctx context.Context, key string) (int64, error)
+	Close() error
+}
+
+type InvalidationBus interface {
+	Publish(ctx context.Context, invalidation domain.Invalidation) error
+	// Subscribe returns once the subscription is active.
+	Subscribe(ctx context.Context, handler func(domain.Invalidation)) error
 	Close() error
 }
@@ -0,0 +1,28 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+)
+
+type UserEventRepository interface {
+	ListUserEvents(ctx context.Context, after, limit uint64) ([]domain.UserEvent, error)
+	LastUserEventSequence(ctx context.Context) (uint64, error)
+	WaitUserEvents(ctx context.Context, after uint64) error
+	// PruneUserEvents returns how many events were deleted.
+	PruneUserEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OutboxRepository interface {
+	// ClaimPendingEvents hides the events from other relays until lease
+	// expires.
+	ClaimPendingEvents(ctx context.Context, limit uint64, lease time.Duration) ([]domain.DomainEvent, error)
+	MarkEventPublished(ctx context.Context, id uint64) error
+	MarkEventFailed(ctx context.Context, id uint64, retryAt time.Time, reason string) error
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.DomainEvent) error
+}
@@ -99,6 +99,34 @@ func (_m *CacheRepository) Get(ctx context.Context, key string) ([]byte, error)
 	return r0, r1
 }
 
+// Increment provides a mock function with given fields: ctx, key
+func (_m *CacheRepository) Increment(ctx context.Context, key string) (int64, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Increment")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Set provides a mock function with given fields: ctx, key, value, ttl
 func (_m *CacheRepository) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
 	ret := _m.Called(ctx, key, value, ttl)
@@ -117,6 +145,34 @@ func (_m *CacheRepository) Set(ctx context.Context, key string, value []byte, tt
 	return r0
 }
 
+// SetIfAbsent provides a mock function with given fields: ctx, key, value, ttl
+func (_m *CacheRepository) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	ret := _m.Called(ctx, key, value, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetIfAbsent")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, time.Duration) (bool, error)); ok {
+		return rf(ctx, key, value, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, time.Duration) bool); ok {
+		r0 = rf(ctx, key, value, ttl)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, time.Duration) error); ok {
+		r1 = rf(ctx, key, value, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewCacheRepository creates a new instance of CacheRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCacheRepository(t interface {
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.49.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// InvalidationBus is an autogenerated mock type for the InvalidationBus type
+type InvalidationBus struct {
+	mock.Mock
+}
+
+// Close provides a mock function with given fields:
+func (_m *InvalidationBus) Close() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Close")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publish provides a mock function with given fields: ctx, invalidation
+func (_m *InvalidationBus) Publish(ctx context.Context, invalidation domain.Invalidation) error {
+	ret := _m.Called(ctx, invalidation)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Publish")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Invalidation) error); ok {
+		r0 = rf(ctx, invalidation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Subscribe provides a mock function with given fields: ctx, handler
+func (_m *InvalidationBus) Subscribe(ctx context.Context, handler func(domain.Invalidation)) error {
+	ret := _m.Called(ctx, handler)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Subscribe")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(domain.Invalidation)) error); ok {
+		r0 = rf(ctx, handler)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewInvalidationBus creates a new instance of InvalidationBus. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewInvalidationBus(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *InvalidationBus {
+	mock := &InvalidationBus{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
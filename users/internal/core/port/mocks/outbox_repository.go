@@ -0,0 +1,97 @@
+// Code generated by mockery v2.49.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// OutboxRepository is an autogenerated mock type for the OutboxRepository type
+type OutboxRepository struct {
+	mock.Mock
+}
+
+// ClaimPendingEvents provides a mock function with given fields: ctx, limit, lease
+func (_m *OutboxRepository) ClaimPendingEvents(ctx context.Context, limit uint64, lease time.Duration) ([]domain.DomainEvent, error) {
+	ret := _m.Called(ctx, limit, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimPendingEvents")
+	}
+
+	var r0 []domain.DomainEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Duration) ([]domain.DomainEvent, error)); ok {
+		return rf(ctx, limit, lease)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Duration) []domain.DomainEvent); ok {
+		r0 = rf(ctx, limit, lease)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.DomainEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, time.Duration) error); ok {
+		r1 = rf(ctx, limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkEventFailed provides a mock function with given fields: ctx, id, retryAt, reason
+func (_m *OutboxRepository) MarkEventFailed(ctx context.Context, id uint64, retryAt time.Time, reason string) error {
+	ret := _m.Called(ctx, id, retryAt, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkEventFailed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, string) error); ok {
+		r0 = rf(ctx, id, retryAt, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkEventPublished provides a mock function with given fields: ctx, id
+func (_m *OutboxRepository) MarkEventPublished(ctx context.Context, id uint64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkEventPublished")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewOutboxRepository creates a new instance of OutboxRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOutboxRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OutboxRepository {
+	mock := &OutboxRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,135 @@
+// Code generated by mockery v2.49.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// UserEventRepository is an autogenerated mock type for the UserEventRepository type
+type UserEventRepository struct {
+	mock.Mock
+}
+
+// LastUserEventSequence provides a mock function with given fields: ctx
+func (_m *UserEventRepository) LastUserEventSequence(ctx context.Context) (uint64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LastUserEventSequence")
+	}
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (uint64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) uint64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUserEvents provides a mock function with given fields: ctx, after, limit
+func (_m *UserEventRepository) ListUserEvents(ctx context.Context, after uint64, limit uint64) ([]domain.UserEvent, error) {
+	ret := _m.Called(ctx, after, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUserEvents")
+	}
+
+	var r0 []domain.UserEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) ([]domain.UserEvent, error)); ok {
+		return rf(ctx, after, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) []domain.UserEvent); ok {
+		r0 = rf(ctx, after, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.UserEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, uint64) error); ok {
+		r1 = rf(ctx, after, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PruneUserEvents provides a mock function with given fields: ctx, before
+func (_m *UserEventRepository) PruneUserEvents(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PruneUserEvents")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WaitUserEvents provides a mock function with given fields: ctx, after
+func (_m *UserEventRepository) WaitUserEvents(ctx context.Context, after uint64) error {
+	ret := _m.Called(ctx, after)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WaitUserEvents")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, after)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewUserEventRepository creates a new instance of UserEventRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUserEventRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UserEventRepository {
+	mock := &UserEventRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
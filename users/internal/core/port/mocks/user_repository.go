@@ -14,6 +14,94 @@ type UserRepository struct {
 	mock.Mock
 }
 
+// AdminExists provides a mock function with given fields: ctx
+func (_m *UserRepository) AdminExists(ctx context.Context) (bool, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdminExists")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (bool, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) bool); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimInvitation provides a mock function with given fields: ctx, tokenHash
+func (_m *UserRepository) ClaimInvitation(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimInvitation")
+	}
+
+	var r0 *domain.Invitation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Invitation, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Invitation); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Invitation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateInvitation provides a mock function with given fields: ctx, invitation
+func (_m *UserRepository) CreateInvitation(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error) {
+	ret := _m.Called(ctx, invitation)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateInvitation")
+	}
+
+	var r0 *domain.Invitation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Invitation) (*domain.Invitation, error)); ok {
+		return rf(ctx, invitation)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Invitation) *domain.Invitation); ok {
+		r0 = rf(ctx, invitation)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Invitation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Invitation) error); ok {
+		r1 = rf(ctx, invitation)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateUser provides a mock function with given fields: ctx, user
 func (_m *UserRepository) CreateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
 	ret := _m.Called(ctx, user)
@@ -122,6 +210,96 @@ func (_m *UserRepository) GetUserById(ctx context.Context, id uint64) (*domain.U
 	return r0, r1
 }
 
+// GetUserByIdForUpdate provides a mock function with given fields: ctx, id
+func (_m *UserRepository) GetUserByIdForUpdate(ctx context.Context, id uint64) (*domain.User, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByIdForUpdate")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.User, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListLoginEvents provides a mock function with given fields: ctx, id, skip, limit
+func (_m *UserRepository) ListLoginEvents(ctx context.Context, id uint64, skip uint64, limit uint64) ([]domain.LoginEvent, error) {
+	ret := _m.Called(ctx, id, skip, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListLoginEvents")
+	}
+
+	var r0 []domain.LoginEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64, uint64) ([]domain.LoginEvent, error)); ok {
+		return rf(ctx, id, skip, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64, uint64) []domain.LoginEvent); ok {
+		r0 = rf(ctx, id, skip, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.LoginEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, uint64, uint64) error); ok {
+		r1 = rf(ctx, id, skip, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPasswordHistory provides a mock function with given fields: ctx, id, limit
+func (_m *UserRepository) ListPasswordHistory(ctx context.Context, id uint64, limit uint64) ([]string, error) {
+	ret := _m.Called(ctx, id, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPasswordHistory")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) ([]string, error)); ok {
+		return rf(ctx, id, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) []string); ok {
+		r0 = rf(ctx, id, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, uint64) error); ok {
+		r1 = rf(ctx, id, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListUsers provides a mock function with given fields: ctx, skip, limit
 func (_m *UserRepository) ListUsers(ctx context.Context, skip uint64, limit uint64) ([]domain.User, error) {
 	ret := _m.Called(ctx, skip, limit)
@@ -152,6 +330,36 @@ func (_m *UserRepository) ListUsers(ctx context.Context, skip uint64, limit uint
 	return r0, r1
 }
 
+// RecordLogin provides a mock function with given fields: ctx, event
+func (_m *UserRepository) RecordLogin(ctx context.Context, event *domain.LoginEvent) (*domain.LoginEvent, error) {
+	ret := _m.Called(ctx, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordLogin")
+	}
+
+	var r0 *domain.LoginEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.LoginEvent) (*domain.LoginEvent, error)); ok {
+		return rf(ctx, event)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.LoginEvent) *domain.LoginEvent); ok {
+		r0 = rf(ctx, event)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LoginEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.LoginEvent) error); ok {
+		r1 = rf(ctx, event)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateUser provides a mock function with given fields: ctx, user
 func (_m *UserRepository) UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
 	ret := _m.Called(ctx, user)
@@ -14,6 +14,54 @@ type UserService struct {
 	mock.Mock
 }
 
+// AcceptInvitation provides a mock function with given fields: ctx, token, name, password
+func (_m *UserService) AcceptInvitation(ctx context.Context, token string, name string, password string) (*domain.User, error) {
+	ret := _m.Called(ctx, token, name, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AcceptInvitation")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*domain.User, error)); ok {
+		return rf(ctx, token, name, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *domain.User); ok {
+		r0 = rf(ctx, token, name, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, token, name, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePassword provides a mock function with given fields: ctx, currentPassword, newPassword
+func (_m *UserService) ChangePassword(ctx context.Context, currentPassword string, newPassword string) error {
+	ret := _m.Called(ctx, currentPassword, newPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, currentPassword, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteUser provides a mock function with given fields: ctx, id
 func (_m *UserService) DeleteUser(ctx context.Context, id uint64) error {
 	ret := _m.Called(ctx, id)
@@ -32,6 +80,36 @@ func (_m *UserService) DeleteUser(ctx context.Context, id uint64) error {
 	return r0
 }
 
+// GetMe provides a mock function with given fields: ctx
+func (_m *UserService) GetMe(ctx context.Context) (*domain.User, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMe")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*domain.User, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *domain.User); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUser provides a mock function with given fields: ctx, id
 func (_m *UserService) GetUser(ctx context.Context, id uint64) (*domain.User, error) {
 	ret := _m.Called(ctx, id)
@@ -62,6 +140,66 @@ func (_m *UserService) GetUser(ctx context.Context, id uint64) (*domain.User, er
 	return r0, r1
 }
 
+// InviteUser provides a mock function with given fields: ctx, email, role
+func (_m *UserService) InviteUser(ctx context.Context, email string, role domain.Role) (*domain.Invitation, error) {
+	ret := _m.Called(ctx, email, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InviteUser")
+	}
+
+	var r0 *domain.Invitation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Role) (*domain.Invitation, error)); ok {
+		return rf(ctx, email, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Role) *domain.Invitation); ok {
+		r0 = rf(ctx, email, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Invitation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.Role) error); ok {
+		r1 = rf(ctx, email, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListLoginEvents provides a mock function with given fields: ctx, id, skip, limit
+func (_m *UserService) ListLoginEvents(ctx context.Context, id uint64, skip uint64, limit uint64) ([]domain.LoginEvent, error) {
+	ret := _m.Called(ctx, id, skip, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListLoginEvents")
+	}
+
+	var r0 []domain.LoginEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64, uint64) ([]domain.LoginEvent, error)); ok {
+		return rf(ctx, id, skip, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64, uint64) []domain.LoginEvent); ok {
+		r0 = rf(ctx, id, skip, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.LoginEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, uint64, uint64) error); ok {
+		r1 = rf(ctx, id, skip, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListUsers provides a mock function with given fields: ctx, skip, limit
 func (_m *UserService) ListUsers(ctx context.Context, skip uint64, limit uint64) ([]domain.User, error) {
 	ret := _m.Called(ctx, skip, limit)
@@ -122,6 +260,36 @@ func (_m *UserService) Register(ctx context.Context, user *domain.User) (*domain
 	return r0, r1
 }
 
+// UpdateMe provides a mock function with given fields: ctx, user
+func (_m *UserService) UpdateMe(ctx context.Context, user *domain.User) (*domain.User, error) {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMe")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) (*domain.User, error)); ok {
+		return rf(ctx, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) *domain.User); ok {
+		r0 = rf(ctx, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateUser provides a mock function with given fields: ctx, user
 func (_m *UserService) UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
 	ret := _m.Called(ctx, user)
@@ -152,6 +320,54 @@ func (_m *UserService) UpdateUser(ctx context.Context, user *domain.User) (*doma
 	return r0, r1
 }
 
+// VerifyCredentials provides a mock function with given fields: ctx, email, password, origin
+func (_m *UserService) VerifyCredentials(ctx context.Context, email string, password string, origin domain.LoginOrigin) (*domain.User, error) {
+	ret := _m.Called(ctx, email, password, origin)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyCredentials")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, domain.LoginOrigin) (*domain.User, error)); ok {
+		return rf(ctx, email, password, origin)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, domain.LoginOrigin) *domain.User); ok {
+		r0 = rf(ctx, email, password, origin)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, domain.LoginOrigin) error); ok {
+		r1 = rf(ctx, email, password, origin)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchUsers provides a mock function with given fields: ctx, since, send
+func (_m *UserService) WatchUsers(ctx context.Context, since uint64, send func(*domain.UserEvent) error) error {
+	ret := _m.Called(ctx, since, send)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WatchUsers")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, func(*domain.UserEvent) error) error); ok {
+		r0 = rf(ctx, since, send)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewUserService creates a new instance of UserService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserService(t interface {
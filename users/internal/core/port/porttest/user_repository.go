@@ -0,0 +1,455 @@
+// Package porttest holds the contract tests of the ports.
+package porttest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// UserRepositorySetup returns an empty repository.
+type UserRepositorySetup func(t *testing.T) (port.UserRepository, port.Transactor)
+
+// TestUserRepository calls setup once per subtest.
+func TestUserRepository(t *testing.T, setup UserRepositorySetup) {
+	ctx := context.Background()
+
+	newUser := func() *domain.User {
+		return &domain.User{
+			Name:     gofakeit.Name(),
+			Email:    gofakeit.Email(),
+			Password: gofakeit.Password(true, true, true, true, false, 12),
+		}
+	}
+
+	create := func(t *testing.T, repo port.UserRepository) *domain.User {
+		user, err := repo.CreateUser(ctx, newUser())
+		require.NoError(t, err)
+		return user
+	}
+
+	t.Run("CreateUser", func(t *testing.T) {
+		repo, _ := setup(t)
+
+		input := newUser()
+		first, err := repo.CreateUser(ctx, input)
+		require.NoError(t, err)
+		assert.NotZero(t, first.ID, "Id not assigned")
+		assert.Equal(t, input.Email, first.Email)
+		assert.Equal(t, domain.Reader, first.Role, "Default role mismatch")
+		assert.False(t, first.CreatedAt.IsZero(), "Creation time not set")
+		assert.False(t, first.PasswordChangedAt.IsZero(), "Password change time not set")
+
+		second := create(t, repo)
+		assert.Greater(t, second.ID, first.ID, "Ids not increasing")
+	})
+
+	t.Run("CreateUser_WithRole", func(t *testing.T) {
+		repo, _ := setup(t)
+
+		input := newUser()
+		input.Role = domain.Agent
+		created, err := repo.CreateUser(ctx, input)
+		require.NoError(t, err)
+		assert.Equal(t, domain.Agent, created.Role)
+
+		got, err := repo.GetUserById(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.Agent, got.Role, "Role not stored")
+	})
+
+	t.Run("CreateUser_DuplicateEmail", func(t *testing.T) {
+		repo, _ := setup(t)
+		existing := create(t, repo)
+
+		duplicate := newUser()
+		duplicate.Email = existing.Email
+		_, err := repo.CreateUser(ctx, duplicate)
+		assert.Equal(t, domain.ErrorConflictData, err)
+	})
+
+	t.Run("GetUserById", func(t *testing.T) {
+		repo, _ := setup(t)
+		created := create(t, repo)
+
+		user, err := repo.GetUserById(ctx, created.ID)
+		require.NoError(t, err)
+		assertSameUser(t, created, user)
+
+		user, err = repo.GetUserByIdForUpdate(ctx, created.ID)
+		require.NoError(t, err)
+		assertSameUser(t, created, user)
+
+		_, err = repo.GetUserById(ctx, created.ID+1)
+		assert.Equal(t, domain.ErrorDataNotFound, err)
+
+		_, err = repo.GetUserByIdForUpdate(ctx, created.ID+1)
+		assert.Equal(t, domain.ErrorDataNotFound, err)
+	})
+
+	t.Run("GetUserByEmail", func(t *testing.T) {
+		repo, _ := setup(t)
+		created := create(t, repo)
+
+		user, err := repo.GetUserByEmail(ctx, created.Email)
+		require.NoError(t, err)
+		assertSameUser(t, created, user)
+
+		_, err = repo.GetUserByEmail(ctx, "missing"+created.Email)
+		assert.Equal(t, domain.ErrorDataNotFound, err)
+	})
+
+	t.Run("ListUsers", func(t *testing.T) {
+		repo, _ := setup(t)
+
+		users, err := repo.ListUsers(ctx, 1, 10)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+
+		var ids []uint64
+		for range 5 {
+			ids = append(ids, create(t, repo).ID)
+		}
+
+		testCases := []struct {
+			skip, limit uint64
+			ids         []uint64
+		}{
+			{1, 2, ids[:2]},
+			{2, 2, ids[2:4]},
+			{3, 2, ids[4:]},
+			{4, 2, nil},
+			{1, 10, ids},
+		}
+
+		for _, tc := range testCases {
+			users, err := repo.ListUsers(ctx, tc.skip, tc.limit)
+			require.NoError(t, err)
+
+			var got []uint64
+			for _, user := range users {
+				got = append(got, user.ID)
+			}
+			assert.Equal(t, tc.ids, got, "Page %d of %d mismatch", tc.skip, tc.limit)
+		}
+	})
+
+	t.Run("AdminExists", func(t *testing.T) {
+		repo, _ := setup(t)
+		create(t, repo)
+
+		exists, err := repo.AdminExists(ctx)
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		admin := newUser()
+		admin.Role = domain.Admin
+		_, err = repo.CreateUser(ctx, admin)
+		require.NoError(t, err)
+
+		exists, err = repo.AdminExists(ctx)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("AdminExists_Concurrent", func(t *testing.T) {
+		repo, tx := setup(t)
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := tx.WithinTx(ctx, func(ctx context.Context) error {
+					exists, err := repo.AdminExists(ctx)
+					if err != nil || exists {
+						return err
+					}
+					admin := newUser()
+					admin.Role = domain.Admin
+					_, err = repo.CreateUser(ctx, admin)
+					return err
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		users, err := repo.ListUsers(ctx, 1, 10)
+		require.NoError(t, err)
+		assert.Len(t, users, 1, "More than one caller acted on no admin existing")
+	})
+
+	t.Run("UpdateUser", func(t *testing.T) {
+		repo, _ := setup(t)
+		created := create(t, repo)
+
+		name := gofakeit.Name()
+		updated, err := repo.UpdateUser(ctx, &domain.User{ID: created.ID, Name: name, Role: domain.Admin})
+		require.NoError(t, err)
+		assert.Equal(t, name, updated.Name)
+		assert.Equal(t, domain.Admin, updated.Role)
+		assert.Equal(t, created.Email, updated.Email, "Empty email overwritten")
+		assert.Equal(t, created.Password, updated.Password, "Empty password overwritten")
+		assert.False(t, updated.UpdatedAt.Before(created.UpdatedAt), "Update time moved back")
+
+		user, err := repo.GetUserById(ctx, created.ID)
+		require.NoError(t, err)
+		assertSameUser(t, updated, user)
+	})
+
+	t.Run("UpdateUser_Missing", func(t *testing.T) {
+		repo, _ := setup(t)
+		created := create(t, repo)
+
+		_, err := repo.UpdateUser(ctx, &domain.User{ID: created.ID + 1, Name: gofakeit.Name()})
+		assert.Equal(t, domain.ErrorDataNotFound, err)
+	})
+
+	t.Run("UpdateUser_DuplicateEmail", func(t *testing.T) {
+		repo, _ := setup(t)
+		first := create(t, repo)
+		second := create(t, repo)
+
+		_, err := repo.UpdateUser(ctx, &domain.User{ID: second.ID, Email: first.Email})
+		assert.Equal(t, domain.ErrorConflictData, err)
+
+		_, err = repo.UpdateUser(ctx, &domain.User{ID: second.ID, Email: second.Email})
+		assert.NoError(t, err, "Own email reported as conflict")
+	})
+
+	t.Run("PasswordHistory", func(t *testing.T) {
+		repo, _ := setup(t)
+		created := create(t, repo)
+		other := create(t, repo)
+
+		hashes, err := repo.ListPasswordHistory(ctx, created.ID, 10)
+		require.NoError(t, err)
+		assert.Empty(t, hashes)
+
+		renamed, err := repo.UpdateUser(ctx, &domain.User{ID: created.ID, Name: gofakeit.Name()})
+		require.NoError(t, err)
+		assert.True(t, created.PasswordChangedAt.Equal(renamed.PasswordChangedAt), "Password change time moved without a password")
+
+		passwords := []string{created.Password}
+		for i := range 3 {
+			password := gofakeit.Password(true, true, true, true, false, 12)
+			updated, err := repo.UpdateUser(ctx, &domain.User{ID: created.ID, Password: password})
+			require.NoError(t, err)
+			assert.False(t, updated.PasswordChangedAt.Before(created.PasswordChangedAt), "Password change time moved back at %d", i)
+			passwords = append(passwords, password)
+		}
+
+		hashes, err = repo.ListPasswordHistory(ctx, created.ID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{passwords[2], passwords[1]}, hashes, "Latest replaced passwords mismatch")
+
+		hashes, err = repo.ListPasswordHistory(ctx, other.ID, 10)
+		require.NoError(t, err)
+		assert.Empty(t, hashes, "History shared between users")
+
+		require.NoError(t, repo.DeleteUser(ctx, created.ID))
+		hashes, err = repo.ListPasswordHistory(ctx, created.ID, 10)
+		require.NoError(t, err)
+		assert.Empty(t, hashes, "History kept after delete")
+	})
+
+	t.Run("LoginEvents", func(t *testing.T) {
+		repo, _ := setup(t)
+		created := create(t, repo)
+		other := create(t, repo)
+		assert.True(t, created.LastLoginAt.IsZero(), "Last login time set before any login")
+
+		origin := domain.LoginOrigin{IPAddress: gofakeit.IPv4Address(), UserAgent: gofakeit.UserAgent()}
+
+		failed, err := repo.RecordLogin(ctx, &domain.LoginEvent{UserID: created.ID, Email: created.Email, Reason: domain.LoginFailureWrongPassword, LoginOrigin: origin})
+		require.NoError(t, err)
+		assert.NotZero(t, failed.ID, "Id not assigned")
+		assert.False(t, failed.CreatedAt.IsZero(), "Creation time not set")
+
+		got, err := repo.GetUserById(ctx, created.ID)
+		require.NoError(t, err)
+		assert.True(t, got.LastLoginAt.IsZero(), "Failed login set the last login time")
+
+		succeeded, err := repo.RecordLogin(ctx, &domain.LoginEvent{UserID: created.ID, Email: created.Email, Success: true, LoginOrigin: origin})
+		require.NoError(t, err)
+
+		got, err = repo.GetUserById(ctx, created.ID)
+		require.NoError(t, err)
+		assert.True(t, succeeded.CreatedAt.Equal(got.LastLoginAt), "Last login time mismatch")
+		assert.True(t, created.UpdatedAt.Equal(got.UpdatedAt), "Login changed the update time")
+
+		_, err = repo.RecordLogin(ctx, &domain.LoginEvent{Email: gofakeit.Email(), Reason: domain.LoginFailureUnknownEmail, LoginOrigin: origin})
+		require.NoError(t, err)
+		_, err = repo.RecordLogin(ctx, &domain.LoginEvent{UserID: other.ID, Email: other.Email, Success: true, LoginOrigin: origin})
+		require.NoError(t, err)
+
+		_, err = repo.RecordLogin(ctx, &domain.LoginEvent{UserID: other.ID + 100, Email: gofakeit.Email(), Success: true, LoginOrigin: origin})
+		assert.Equal(t, domain.ErrorDataNotFound, err, "Login of a missing user recorded")
+
+		all, err := repo.ListLoginEvents(ctx, 0, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, all, 4)
+		assert.Equal(t, other.ID, all[0].UserID, "Events not latest first")
+		assert.Zero(t, all[1].UserID, "Unknown email got a user")
+		assert.Equal(t, domain.LoginFailureUnknownEmail, all[1].Reason)
+
+		events, err := repo.ListLoginEvents(ctx, created.ID, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, succeeded.ID, events[0].ID)
+		assert.True(t, events[0].Success)
+		assert.Empty(t, events[0].Reason)
+		assert.Equal(t, origin, events[0].LoginOrigin)
+		assert.True(t, succeeded.CreatedAt.Equal(events[0].CreatedAt), "Creation time mismatch")
+		assert.False(t, events[1].Success)
+		assert.Equal(t, domain.LoginFailureWrongPassword, events[1].Reason)
+
+		page, err := repo.ListLoginEvents(ctx, created.ID, 2, 1)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, failed.ID, page[0].ID, "Second page mismatch")
+
+		require.NoError(t, repo.DeleteUser(ctx, created.ID))
+		events, err = repo.ListLoginEvents(ctx, created.ID, 1, 10)
+		require.NoError(t, err)
+		assert.Len(t, events, 2, "Events dropped with their user")
+	})
+
+	t.Run("Invitations", func(t *testing.T) {
+		repo, tx := setup(t)
+		admin := create(t, repo)
+
+		newInvitation := func(email string) *domain.Invitation {
+			return &domain.Invitation{
+				Email:     email,
+				Role:      domain.Agent,
+				TokenHash: gofakeit.UUID(),
+				InvitedBy: admin.ID,
+				ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second),
+			}
+		}
+
+		email := gofakeit.Email()
+		first, err := repo.CreateInvitation(ctx, newInvitation(email))
+		require.NoError(t, err)
+		assert.NotZero(t, first.ID, "Id not assigned")
+		assert.False(t, first.CreatedAt.IsZero(), "Creation time not set")
+
+		second, err := repo.CreateInvitation(ctx, newInvitation(email))
+		require.NoError(t, err)
+		assert.Greater(t, second.ID, first.ID, "Ids not increasing")
+		other, err := repo.CreateInvitation(ctx, newInvitation(gofakeit.Email()))
+		require.NoError(t, err)
+
+		_, err = repo.ClaimInvitation(ctx, first.TokenHash)
+		assert.Equal(t, domain.ErrorDataNotFound, err, "Replaced invitation still valid")
+
+		failure := errors.New("failure")
+		err = tx.WithinTx(ctx, func(ctx context.Context) error {
+			if _, err := repo.ClaimInvitation(ctx, second.TokenHash); err != nil {
+				return err
+			}
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		claimed, err := repo.ClaimInvitation(ctx, second.TokenHash)
+		require.NoError(t, err, "Claim not rolled back")
+		assert.Equal(t, second.ID, claimed.ID)
+		assert.Equal(t, email, claimed.Email)
+		assert.Equal(t, domain.Agent, claimed.Role)
+		assert.Equal(t, admin.ID, claimed.InvitedBy)
+		assert.True(t, second.ExpiresAt.Equal(claimed.ExpiresAt), "Expiry time mismatch")
+
+		_, err = repo.ClaimInvitation(ctx, second.TokenHash)
+		assert.Equal(t, domain.ErrorDataNotFound, err, "Invitation claimed twice")
+
+		_, err = repo.ClaimInvitation(ctx, other.TokenHash)
+		assert.NoError(t, err, "Invitation for another email replaced")
+	})
+
+	t.Run("DeleteUser", func(t *testing.T) {
+		repo, _ := setup(t)
+		created := create(t, repo)
+
+		require.NoError(t, repo.DeleteUser(ctx, created.ID))
+
+		_, err := repo.GetUserById(ctx, created.ID)
+		assert.Equal(t, domain.ErrorDataNotFound, err)
+
+		err = repo.DeleteUser(ctx, created.ID)
+		assert.Equal(t, domain.ErrorDataNotFound, err)
+	})
+
+	t.Run("WithinTx_Commit", func(t *testing.T) {
+		repo, tx := setup(t)
+		created := create(t, repo)
+
+		var second *domain.User
+		err := tx.WithinTx(ctx, func(ctx context.Context) error {
+			if _, err := repo.GetUserByIdForUpdate(ctx, created.ID); err != nil {
+				return err
+			}
+			if err := repo.DeleteUser(ctx, created.ID); err != nil {
+				return err
+			}
+			var err error
+			second, err = repo.CreateUser(ctx, newUser())
+			return err
+		})
+		require.NoError(t, err)
+
+		_, err = repo.GetUserById(ctx, created.ID)
+		assert.Equal(t, domain.ErrorDataNotFound, err, "Delete not committed")
+		_, err = repo.GetUserById(ctx, second.ID)
+		assert.NoError(t, err, "Create not committed")
+	})
+
+	t.Run("WithinTx_Rollback", func(t *testing.T) {
+		repo, tx := setup(t)
+		created := create(t, repo)
+		failure := errors.New("rollback")
+
+		var second *domain.User
+		err := tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := repo.DeleteUser(ctx, created.ID); err != nil {
+				return err
+			}
+			var err error
+			second, err = repo.CreateUser(ctx, newUser())
+			if err != nil {
+				return err
+			}
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		_, err = repo.GetUserById(ctx, created.ID)
+		assert.NoError(t, err, "Delete not rolled back")
+		_, err = repo.GetUserById(ctx, second.ID)
+		assert.Equal(t, domain.ErrorDataNotFound, err, "Create not rolled back")
+	})
+}
+
+func assertSameUser(t *testing.T, expected, actual *domain.User) {
+	t.Helper()
+
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Name, actual.Name)
+	assert.Equal(t, expected.Email, actual.Email)
+	assert.Equal(t, expected.Password, actual.Password)
+	assert.Equal(t, expected.Role, actual.Role)
+	assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "Creation time mismatch")
+	assert.True(t, expected.UpdatedAt.Equal(actual.UpdatedAt), "Update time mismatch")
+	assert.True(t, expected.PasswordChangedAt.Equal(actual.PasswordChangedAt), "Password change time mismatch")
+	assert.True(t, expected.LastLoginAt.Equal(actual.LastLoginAt), "Last login time mismatch")
+}
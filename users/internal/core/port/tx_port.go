@@ -0,0 +1,9 @@
+package port
+
+import "context"
+
+type Transactor interface {
+	// WithinTx runs the repository calls made with the context fn receives
+	// in one transaction.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -7,12 +7,28 @@ import (
 )
 
 type UserRepository interface {
+	// CreateUser gives the user domain.Reader as role unless it has one.
 	CreateUser(ctx context.Context, user *domain.User) (*domain.User, error)
 	GetUserById(ctx context.Context, id uint64) (*domain.User, error)
+	// GetUserByIdForUpdate locks the user until the transaction in ctx ends.
+	GetUserByIdForUpdate(ctx context.Context, id uint64) (*domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
 	ListUsers(ctx context.Context, skip, limit uint64) ([]domain.User, error)
+	// AdminExists serializes callers within transactions until theirs ends.
+	AdminExists(ctx context.Context) (bool, error)
 	UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error)
 	DeleteUser(ctx context.Context, id uint64) error
+	// ListPasswordHistory returns the hashes replaced by UpdateUser, latest
+	// first.
+	ListPasswordHistory(ctx context.Context, id uint64, limit uint64) ([]string, error)
+	// RecordLogin sets LastLoginAt on success, without a user event.
+	RecordLogin(ctx context.Context, event *domain.LoginEvent) (*domain.LoginEvent, error)
+	// ListLoginEvents lists everyone's events, latest first, when id is zero.
+	ListLoginEvents(ctx context.Context, id uint64, skip, limit uint64) ([]domain.LoginEvent, error)
+	// CreateInvitation replaces any earlier invitation for the same email.
+	CreateInvitation(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error)
+	// ClaimInvitation deletes the invitation it returns.
+	ClaimInvitation(ctx context.Context, tokenHash string) (*domain.Invitation, error)
 }
 
 type UserService interface {
@@ -21,4 +37,15 @@ type UserService interface {
 	ListUsers(ctx context.Context, skip, limit uint64) ([]domain.User, error)
 	UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error)
 	DeleteUser(ctx context.Context, id uint64) error
+	WatchUsers(ctx context.Context, since uint64, send func(*domain.UserEvent) error) error
+	// GetMe, UpdateMe and ChangePassword act on the caller in ctx.
+	GetMe(ctx context.Context) (*domain.User, error)
+	UpdateMe(ctx context.Context, user *domain.User) (*domain.User, error)
+	ChangePassword(ctx context.Context, currentPassword, newPassword string) error
+	// VerifyCredentials records every attempt as a login event.
+	VerifyCredentials(ctx context.Context, email, password string, origin domain.LoginOrigin) (*domain.User, error)
+	ListLoginEvents(ctx context.Context, id uint64, skip, limit uint64) ([]domain.LoginEvent, error)
+	// InviteUser returns the only copy of the invitation Token.
+	InviteUser(ctx context.Context, email string, role domain.Role) (*domain.Invitation, error)
+	AcceptInvitation(ctx context.Context, token, name, password string) (*domain.User, error)
 }
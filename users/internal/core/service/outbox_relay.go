@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	relayBatchSize    = 100
+	relayPollInterval = time.Second
+	relayLease        = 30 * time.Second
+	relayMinBackoff   = time.Second
+	relayMaxBackoff   = 5 * time.Minute
+)
+
+// OutboxRelay delivers outbox events at least once.
+type OutboxRelay struct {
+	outbox    port.OutboxRepository
+	publisher port.EventPublisher
+	logger    log.Logger
+}
+
+func NewOutboxRelay(outbox port.OutboxRepository, publisher port.EventPublisher, logger log.Logger) *OutboxRelay {
+	return &OutboxRelay{outbox, publisher, logger}
+}
+
+// Run relays pending events until ctx is done.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(relayPollInterval)
+	defer ticker.Stop()
+
+	for {
+		relayed, err := r.RelayPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			level.Error(r.logger).Log("msg", "failed to relay outbox events", "err", err)
+		}
+		if err == nil && relayed == relayBatchSize {
+			continue
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// RelayPending returns how many events were claimed.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	events, err := r.outbox.ClaimPendingEvents(ctx, relayBatchSize, relayLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		err := r.publisher.Publish(ctx, event)
+		if err != nil {
+			retryAt := time.Now().Add(relayBackoff(event.Attempts))
+			if err := r.outbox.MarkEventFailed(ctx, event.ID, retryAt, err.Error()); err != nil {
+				return len(events), err
+			}
+			continue
+		}
+
+		if err := r.outbox.MarkEventPublished(ctx, event.ID); err != nil {
+			return len(events), err
+		}
+	}
+
+	return len(events), nil
+}
+
+func relayBackoff(attempts int) time.Duration {
+	backoff := relayMinBackoff
+	for i := 0; i < attempts && backoff < relayMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, relayMaxBackoff)
+}
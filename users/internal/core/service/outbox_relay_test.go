@@ -0,0 +1,141 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/publisher/memory"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port/mocks"
+	"github.com/OzkrOssa/radiusx-users/internal/core/service"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type relayExpectedOutput struct {
+	relayed   int
+	published []domain.DomainEvent
+	err       error
+}
+
+func TestOutboxRelay_RelayPending(t *testing.T) {
+	ctx := context.Background()
+
+	events := []domain.DomainEvent{
+		{ID: 1, Type: domain.UserRegisteredEvent, UserID: 7, Payload: []byte(`{"id":7}`)},
+		{ID: 2, Type: domain.RoleChangedEvent, UserID: 7, Payload: []byte(`{"user_id":7}`), Attempts: 3},
+	}
+
+	publishErr := errors.New("broker unavailable")
+
+	testCases := []struct {
+		desc       string
+		mocks      func(outbox *mocks.OutboxRepository)
+		publishErr error
+		expected   relayExpectedOutput
+	}{
+		{
+			desc: "Success",
+			mocks: func(outbox *mocks.OutboxRepository) {
+				outbox.On("ClaimPendingEvents", ctx, uint64(100), 30*time.Second).Return(events, nil)
+				outbox.On("MarkEventPublished", ctx, uint64(1)).Return(nil)
+				outbox.On("MarkEventPublished", ctx, uint64(2)).Return(nil)
+			},
+			expected: relayExpectedOutput{
+				relayed:   2,
+				published: events,
+				err:       nil,
+			},
+		},
+		{
+			desc: "Success_NothingPending",
+			mocks: func(outbox *mocks.OutboxRepository) {
+				outbox.On("ClaimPendingEvents", ctx, uint64(100), 30*time.Second).Return(nil, nil)
+			},
+			expected: relayExpectedOutput{
+				relayed:   0,
+				published: nil,
+				err:       nil,
+			},
+		},
+		{
+			desc: "Fail_Claim",
+			mocks: func(outbox *mocks.OutboxRepository) {
+				outbox.On("ClaimPendingEvents", ctx, uint64(100), 30*time.Second).Return(nil, domain.ErrorInternal)
+			},
+			expected: relayExpectedOutput{
+				relayed:   0,
+				published: nil,
+				err:       domain.ErrorInternal,
+			},
+		},
+		{
+			desc: "Fail_PublishReschedules",
+			mocks: func(outbox *mocks.OutboxRepository) {
+				outbox.On("ClaimPendingEvents", ctx, uint64(100), 30*time.Second).Return(events, nil)
+				outbox.On("MarkEventFailed", ctx, uint64(1), mock.MatchedBy(func(retryAt time.Time) bool {
+					return retryAt.After(time.Now())
+				}), publishErr.Error()).Return(nil)
+				outbox.On("MarkEventFailed", ctx, uint64(2), mock.MatchedBy(func(retryAt time.Time) bool {
+					return retryAt.After(time.Now().Add(7 * time.Second))
+				}), publishErr.Error()).Return(nil)
+			},
+			publishErr: publishErr,
+			expected: relayExpectedOutput{
+				relayed:   2,
+				published: nil,
+				err:       nil,
+			},
+		},
+		{
+			desc: "Fail_MarkPublished",
+			mocks: func(outbox *mocks.OutboxRepository) {
+				outbox.On("ClaimPendingEvents", ctx, uint64(100), 30*time.Second).Return(events, nil)
+				outbox.On("MarkEventPublished", ctx, uint64(1)).Return(domain.ErrorInternal)
+			},
+			expected: relayExpectedOutput{
+				relayed:   2,
+				published: events[:1],
+				err:       domain.ErrorInternal,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			outbox := mocks.NewOutboxRepository(t)
+			publisher := memory.New()
+			publisher.Fail(tc.publishErr)
+			tc.mocks(outbox)
+
+			relay := service.NewOutboxRelay(outbox, publisher, log.NewNopLogger())
+
+			relayed, err := relay.RelayPending(ctx)
+			assert.Equal(t, tc.expected.err, err, "Error mismatch")
+			assert.Equal(t, tc.expected.relayed, relayed, "Relayed mismatch")
+			assert.Equal(t, tc.expected.published, publisher.Events(), "Published mismatch")
+		})
+	}
+}
+
+func TestOutboxRelay_Run_LogsErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	outbox := mocks.NewOutboxRepository(t)
+	outbox.On("ClaimPendingEvents", mock.Anything, uint64(100), 30*time.Second).Return(nil, domain.ErrorInternal).Once()
+
+	var logged []interface{}
+	logger := log.LoggerFunc(func(keyvals ...interface{}) error {
+		logged = keyvals
+		cancel()
+		return nil
+	})
+
+	err := service.NewOutboxRelay(outbox, memory.New(), logger).Run(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, logged, domain.ErrorInternal, "Relay error not logged")
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const pruneInterval = time.Hour
+
+// UserEventPruner deletes user events older than the retention, so watchers
+// can resume from at most that far back.
+type UserEventPruner struct {
+	events    port.UserEventRepository
+	retention time.Duration
+	logger    log.Logger
+}
+
+func NewUserEventPruner(events port.UserEventRepository, retention time.Duration, logger log.Logger) *UserEventPruner {
+	return &UserEventPruner{events, retention, logger}
+}
+
+// Run prunes events every hour until ctx is done.
+func (p *UserEventPruner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		pruned, err := p.Prune(ctx)
+		if err != nil && ctx.Err() == nil {
+			level.Error(p.logger).Log("msg", "failed to prune user events", "err", err)
+		}
+		if pruned > 0 {
+			level.Info(p.logger).Log("msg", "pruned user events", "count", pruned)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (p *UserEventPruner) Prune(ctx context.Context) (int64, error) {
+	return p.events.PruneUserEvents(ctx, time.Now().Add(-p.retention))
+}
@@ -0,0 +1,67 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port/mocks"
+	"github.com/OzkrOssa/radiusx-users/internal/core/service"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type pruneExpectedOutput struct {
+	pruned int64
+	err    error
+}
+
+func TestUserEventPruner_Prune(t *testing.T) {
+	ctx := context.Background()
+	retention := 24 * time.Hour
+
+	cutoff := mock.MatchedBy(func(before time.Time) bool {
+		age := time.Since(before)
+		return age >= retention && age < retention+time.Minute
+	})
+
+	testCases := []struct {
+		desc     string
+		mocks    func(events *mocks.UserEventRepository)
+		expected pruneExpectedOutput
+	}{
+		{
+			desc: "Success",
+			mocks: func(events *mocks.UserEventRepository) {
+				events.On("PruneUserEvents", ctx, cutoff).Return(int64(3), nil)
+			},
+			expected: pruneExpectedOutput{
+				pruned: 3,
+				err:    nil,
+			},
+		},
+		{
+			desc: "Fail",
+			mocks: func(events *mocks.UserEventRepository) {
+				events.On("PruneUserEvents", ctx, cutoff).Return(int64(0), domain.ErrorInternal)
+			},
+			expected: pruneExpectedOutput{
+				pruned: 0,
+				err:    domain.ErrorInternal,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			events := mocks.NewUserEventRepository(t)
+			tc.mocks(events)
+
+			pruned, err := service.NewUserEventPruner(events, retention, log.NewNopLogger()).Prune(ctx)
+			assert.Equal(t, tc.expected.err, err, "Error mismatch")
+			assert.Equal(t, tc.expected.pruned, pruned, "Pruned mismatch")
+		})
+	}
+}
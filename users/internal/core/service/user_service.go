@@ -1,57 +1,112 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"sync"
+	"time"
 
 	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
 	"github.com/OzkrOssa/radiusx-users/internal/core/port"
 	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"golang.org/x/sync/singleflight"
 )
 
+const (
+	watchBatchSize = 100
+	// A gap in the event sequence is an id taken by a transaction that has
+	// not committed yet, or never will; watchers wait watchGapTimeout for it
+	// before skipping it.
+	watchGapTimeout      = 5 * time.Second
+	watchGapPollInterval = 100 * time.Millisecond
+
+	userCacheTTL     = 15 * time.Minute
+	listCacheTTL     = 5 * time.Minute
+	notFoundCacheTTL = 30 * time.Second
+	// sharedCallTimeout bounds a coalesced lookup, which outlives the
+	// caller that started it.
+	sharedCallTimeout = 10 * time.Second
+)
+
+// usersGenerationKey is part of every ListUsers cache key; bumping it orphans
+// all cached pages at once.
+var usersGenerationKey = utils.GenerateCacheKey("users", "generation")
+
+// cachedNotFound marks an id known not to exist.
+var cachedNotFound = []byte("null")
+
+// decoyPassword makes unknown emails take as long to refuse as wrong
+// passwords.
+var decoyPassword = sync.OnceValue(func() string {
+	hash, _ := utils.HashPassword("decoy password")
+	return hash
+})
+
 type UserService struct {
-	repo  port.UserRepository
-	cache port.CacheRepository
+	repo         port.UserRepository
+	cache        port.CacheRepository
+	events       port.UserEventRepository
+	tx           port.Transactor
+	policy       domain.PasswordPolicy
+	registration domain.RegistrationPolicy
+	logger       log.Logger
+	// group coalesces concurrent cache misses for the same key.
+	group *singleflight.Group
 }
 
-func NewUserService(repo port.UserRepository, cache port.CacheRepository) *UserService {
-	return &UserService{repo, cache}
+// NewUserService logs through the request logger in the context when there
+// is one.
+func NewUserService(repo port.UserRepository, cache port.CacheRepository, events port.UserEventRepository, tx port.Transactor, policy domain.PasswordPolicy, registration domain.RegistrationPolicy, logger log.Logger) *UserService {
+	return &UserService{repo, cache, events, tx, policy, registration, logger, &singleflight.Group{}}
 }
 
 func (u UserService) Register(ctx context.Context, user *domain.User) (*domain.User, error) {
+	if u.registration.InviteOnly {
+		return nil, domain.ErrorRegistrationClosed
+	}
+
 	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "hash password", err)
 	}
 
+	// Registered users always start as readers.
 	user.Password = hashedPassword
+	user.Role = ""
 
 	user, err = u.repo.CreateUser(ctx, user)
 	if err != nil {
 		if errors.Is(err, domain.ErrorConflictData) {
 			return nil, err
 		}
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "create user", err)
 	}
 
+	return u.cacheNewUser(ctx, user)
+}
+
+func (u UserService) cacheNewUser(ctx context.Context, user *domain.User) (*domain.User, error) {
 	key := utils.GenerateCacheKey("user", user.ID)
 
 	serializedUser, err := utils.Serialize(user)
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "serialize user", err)
 	}
 
-	err = u.cache.Set(ctx, key, serializedUser, 0)
+	err = u.cache.Set(ctx, key, serializedUser, utils.JitterTTL(userCacheTTL))
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "cache user", err)
 	}
 
-	err = u.cache.DeleteByPrefix(ctx, "users:*")
+	_, err = u.cache.Increment(ctx, usersGenerationKey)
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "bump users generation", err)
 	}
 
-	return user, nil
+	return u.withExpiry(user), nil
 }
 
 func (u UserService) GetUser(ctx context.Context, id uint64) (*domain.User, error) {
@@ -60,32 +115,46 @@ func (u UserService) GetUser(ctx context.Context, id uint64) (*domain.User, erro
 	cachedUser, err := u.cache.Get(ctx, cacheKey)
 
 	if err == nil {
+		if bytes.Equal(cachedUser, cachedNotFound) {
+			return nil, domain.ErrorDataNotFound
+		}
 		err := utils.Deserialize(cachedUser, &user)
 		if err != nil {
-			return nil, domain.ErrorInternal
+			return nil, u.internal(ctx, "deserialize cached user", err)
 		}
-		return user, nil
+		return u.withExpiry(user), nil
 	}
 
-	user, err = u.repo.GetUserById(ctx, id)
-	if err != nil {
-		if errors.Is(err, domain.ErrorDataNotFound) {
-			return nil, err
+	result, err := u.shared(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		user, err := u.repo.GetUserById(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrorDataNotFound) {
+				err := u.cache.Set(ctx, cacheKey, cachedNotFound, utils.JitterTTL(notFoundCacheTTL))
+				if err != nil {
+					return nil, u.internal(ctx, "cache user not found", err)
+				}
+				return nil, domain.ErrorDataNotFound
+			}
+			return nil, u.internal(ctx, "get user", err)
 		}
-		return nil, domain.ErrorInternal
-	}
 
-	userSerialized, err := utils.Serialize(user)
-	if err != nil {
-		return nil, domain.ErrorInternal
-	}
+		userSerialized, err := utils.Serialize(user)
+		if err != nil {
+			return nil, u.internal(ctx, "serialize user", err)
+		}
+
+		err = u.cache.Set(ctx, cacheKey, userSerialized, utils.JitterTTL(userCacheTTL))
+		if err != nil {
+			return nil, u.internal(ctx, "cache user", err)
+		}
 
-	err = u.cache.Set(ctx, cacheKey, userSerialized, 0)
+		return user, nil
+	})
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, err
 	}
 
-	return user, nil
+	return u.withExpiry(result.(*domain.User)), nil
 
 }
 
@@ -93,121 +162,543 @@ func (u UserService) ListUsers(ctx context.Context, skip, limit uint64) ([]domai
 
 	var users []domain.User
 
-	params := utils.GenerateCacheKeyParams(skip, limit)
+	// The generation is read before the repository so a fill racing with a
+	// write lands under the old generation, where no reader will look.
+	generation, err := u.cache.Get(ctx, usersGenerationKey)
+	if errors.Is(err, domain.ErrorDataNotFound) {
+		generation = []byte("0")
+	} else if err != nil {
+		// Without the generation, cached pages cannot be told from stale ones.
+		users, err := u.repo.ListUsers(ctx, skip, limit)
+		if err != nil {
+			return nil, u.internal(ctx, "list users", err)
+		}
+		return u.withExpiries(users), nil
+	}
+
+	params := utils.GenerateCacheKeyParams(string(generation), skip, limit)
 	cacheKey := utils.GenerateCacheKey("users", params)
 
 	cachedUsers, err := u.cache.Get(ctx, cacheKey)
 	if err == nil {
 		err := utils.Deserialize(cachedUsers, &users)
 		if err != nil {
-			return nil, domain.ErrorInternal
+			return nil, u.internal(ctx, "deserialize cached users", err)
 		}
+		return u.withExpiries(users), nil
+	}
+
+	result, err := u.shared(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		users, err := u.repo.ListUsers(ctx, skip, limit)
+		if err != nil {
+			return nil, u.internal(ctx, "list users", err)
+		}
+
+		usersSerialized, err := utils.Serialize(users)
+		if err != nil {
+			return nil, u.internal(ctx, "serialize users", err)
+		}
+
+		err = u.cache.Set(ctx, cacheKey, usersSerialized, utils.JitterTTL(listCacheTTL))
+		if err != nil {
+			return nil, u.internal(ctx, "cache users", err)
+		}
+
 		return users, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.withExpiries(result.([]domain.User)), nil
+}
+
+// shared runs fn once for concurrent callers of key, each of which stops
+// waiting when its own ctx is done.
+func (u UserService) shared(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	results := u.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedCallTimeout)
+		defer cancel()
+		return fn(ctx)
+	})
+
+	select {
+	case result := <-results:
+		return result.Val, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (u UserService) UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		existingUser, err := u.repo.GetUserByIdForUpdate(ctx, user.ID)
+		if err != nil {
+			if errors.Is(err, domain.ErrorDataNotFound) {
+				return err
+			}
+			return u.internal(ctx, "lock user", err)
+		}
+
+		emptyData := user.Name == "" &&
+			user.Email == "" &&
+			user.Password == "" &&
+			user.Role == ""
+
+		sameData := (user.Name == "" || existingUser.Name == user.Name) &&
+			(user.Email == "" || existingUser.Email == user.Email) &&
+			user.Password == "" &&
+			(user.Role == "" || existingUser.Role == user.Role)
+
+		if emptyData || sameData {
+			return domain.ErrorNoUpdatedData
+		}
+
+		if user.Password != "" {
+			err = u.checkPasswordReuse(ctx, existingUser, user.Password)
+			if err != nil {
+				return err
+			}
+
+			user.Password, err = utils.HashPassword(user.Password)
+			if err != nil {
+				level.Warn(u.log(ctx)).Log("msg", "hash password", "err", err)
+				return domain.ErrorConflictData
+			}
+		}
+
+		_, err = u.repo.UpdateUser(ctx, user)
+		if err != nil {
+			if errors.Is(err, domain.ErrorConflictData) {
+				return err
+			}
+			return u.internal(ctx, "update user", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, u.txError(ctx, err)
+	}
+
+	cacheKey := utils.GenerateCacheKey("user", user.ID)
+
+	err = u.cache.Delete(ctx, cacheKey)
+	if err != nil {
+		return nil, u.internal(ctx, "invalidate user", err)
 	}
 
-	users, err = u.repo.ListUsers(ctx, skip, limit)
+	userSerialized, err := utils.Serialize(user)
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "serialize user", err)
 	}
 
-	usersSerialized, err := utils.Serialize(users)
+	err = u.cache.Set(ctx, cacheKey, userSerialized, utils.JitterTTL(userCacheTTL))
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "cache user", err)
 	}
 
-	err = u.cache.Set(ctx, cacheKey, usersSerialized, 0)
+	_, err = u.cache.Increment(ctx, usersGenerationKey)
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "bump users generation", err)
 	}
 
-	return users, nil
+	return u.withExpiry(user), nil
 }
 
-func (u UserService) UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
-	existingUser, err := u.repo.GetUserById(ctx, user.ID)
+func (u UserService) DeleteUser(ctx context.Context, id uint64) error {
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := u.repo.GetUserByIdForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrorDataNotFound) {
+				return err
+			}
+			return u.internal(ctx, "lock user", err)
+		}
 
-	if err != nil {
-		if errors.Is(err, domain.ErrorDataNotFound) {
-			return nil, err
+		err = u.repo.DeleteUser(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrorDataNotFound) {
+				return err
+			}
+			return u.internal(ctx, "delete user", err)
 		}
-		return nil, domain.ErrorInternal
+
+		return nil
+	})
+	if err != nil {
+		return u.txError(ctx, err)
 	}
 
-	emptyData := user.Name == "" &&
-		user.Email == "" &&
-		user.Password == ""
+	// Invalidated after commit so a concurrent read cannot refill it.
+	return u.forgetUser(ctx, id)
+}
 
-	sameData := existingUser.Name == user.Name &&
-		existingUser.Email == user.Email
+func (u UserService) GetMe(ctx context.Context) (*domain.User, error) {
+	id, ok := utils.CallerID(ctx)
+	if !ok {
+		return nil, domain.ErrorUnauthenticated
+	}
+
+	return u.GetUser(ctx, id)
+}
 
-	if emptyData || sameData {
-		return nil, domain.ErrorNoUpdatedData
+func (u UserService) UpdateMe(ctx context.Context, user *domain.User) (*domain.User, error) {
+	id, ok := utils.CallerID(ctx)
+	if !ok {
+		return nil, domain.ErrorUnauthenticated
 	}
 
-	var hashedPassword string
+	// Only the profile fields are copied.
+	return u.UpdateUser(ctx, &domain.User{ID: id, Name: user.Name, Email: user.Email})
+}
+
+func (u UserService) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	id, ok := utils.CallerID(ctx)
+	if !ok {
+		return domain.ErrorUnauthenticated
+	}
+
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		existingUser, err := u.repo.GetUserByIdForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrorDataNotFound) {
+				return err
+			}
+			return u.internal(ctx, "lock user", err)
+		}
+
+		if utils.ComparePassword(currentPassword, existingUser.Password) != nil {
+			return domain.ErrorInvalidCredentials
+		}
+		if currentPassword == newPassword {
+			return domain.ErrorNoUpdatedData
+		}
+
+		err = u.checkPasswordReuse(ctx, existingUser, newPassword)
+		if err != nil {
+			return err
+		}
+
+		hashedPassword, err := utils.HashPassword(newPassword)
+		if err != nil {
+			return u.internal(ctx, "hash password", err)
+		}
 
-	if user.Password != "" {
-		hashedPassword, err = utils.HashPassword(user.Password)
+		_, err = u.repo.UpdateUser(ctx, &domain.User{ID: id, Password: hashedPassword})
 		if err != nil {
-			return nil, domain.ErrorConflictData
+			return u.internal(ctx, "update password", err)
 		}
+
+		return nil
+	})
+	if err != nil {
+		return u.txError(ctx, err)
 	}
 
-	user.Password = hashedPassword
+	return u.forgetUser(ctx, id)
+}
+
+func (u UserService) VerifyCredentials(ctx context.Context, email, password string, origin domain.LoginOrigin) (*domain.User, error) {
+	event := &domain.LoginEvent{Email: email, LoginOrigin: origin}
+
+	user, err := u.repo.GetUserByEmail(ctx, email)
+	switch {
+	case errors.Is(err, domain.ErrorDataNotFound):
+		_ = utils.ComparePassword(password, decoyPassword())
+		event.Reason = domain.LoginFailureUnknownEmail
+	case err != nil:
+		return nil, u.internal(ctx, "get user by email", err)
+	case utils.ComparePassword(password, user.Password) != nil:
+		event.UserID = user.ID
+		event.Reason = domain.LoginFailureWrongPassword
+	default:
+		event.UserID = user.ID
+		event.Success = true
+	}
 
-	_, err = u.repo.UpdateUser(ctx, user)
+	event, err = u.repo.RecordLogin(ctx, event)
 	if err != nil {
-		if errors.Is(err, domain.ErrorConflictData) {
-			return nil, err
+		// The user was deleted since it was read.
+		if errors.Is(err, domain.ErrorDataNotFound) {
+			return nil, domain.ErrorInvalidCredentials
 		}
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "record login", err)
 	}
 
-	cacheKey := utils.GenerateCacheKey("user", user.ID)
+	if !event.Success {
+		return nil, domain.ErrorInvalidCredentials
+	}
 
-	err = u.cache.Delete(ctx, cacheKey)
+	user.LastLoginAt = event.CreatedAt
+
+	// Cached pages keep the previous login time until they expire, rather
+	// than every login orphaning all of them. The login stands even if the
+	// cache fails.
+	err = u.cache.Delete(ctx, utils.GenerateCacheKey("user", user.ID))
 	if err != nil {
-		return nil, domain.ErrorInternal
+		level.Warn(u.log(ctx)).Log("msg", "invalidate user", "err", err)
 	}
 
-	userSerialized, err := utils.Serialize(user)
+	return u.withExpiry(user), nil
+}
+
+func (u UserService) ListLoginEvents(ctx context.Context, id uint64, skip, limit uint64) ([]domain.LoginEvent, error) {
+	err := u.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := u.repo.ListLoginEvents(ctx, id, skip, limit)
+	if err != nil {
+		return nil, u.internal(ctx, "list login events", err)
+	}
+
+	return events, nil
+}
+
+func (u UserService) InviteUser(ctx context.Context, email string, role domain.Role) (*domain.Invitation, error) {
+	err := u.requireAdmin(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	err = u.cache.Set(ctx, cacheKey, userSerialized, 0)
+	_, err = u.repo.GetUserByEmail(ctx, email)
+	if err == nil {
+		return nil, domain.ErrorConflictData
+	}
+	if !errors.Is(err, domain.ErrorDataNotFound) {
+		return nil, u.internal(ctx, "get user by email", err)
+	}
+
+	token, tokenHash, err := utils.GenerateToken()
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "generate invitation token", err)
 	}
 
-	err = u.cache.DeleteByPrefix(ctx, "users:*")
+	invitedBy, _ := utils.CallerID(ctx)
+
+	invitation, err := u.repo.CreateInvitation(ctx, &domain.Invitation{
+		Email:     email,
+		Role:      role,
+		TokenHash: tokenHash,
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().Add(u.registration.InvitationTTL),
+	})
 	if err != nil {
-		return nil, domain.ErrorInternal
+		return nil, u.internal(ctx, "create invitation", err)
 	}
 
-	return user, nil
+	invitation.Token = token
+	return invitation, nil
 }
 
-func (u UserService) DeleteUser(ctx context.Context, id uint64) error {
-	_, err := u.repo.GetUserById(ctx, id)
+func (u UserService) AcceptInvitation(ctx context.Context, token, name, password string) (*domain.User, error) {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, u.internal(ctx, "hash password", err)
+	}
+
+	var user *domain.User
+
+	// The invitation is only used up once the user is created.
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		invitation, err := u.repo.ClaimInvitation(ctx, utils.HashToken(token))
+		if err != nil {
+			if errors.Is(err, domain.ErrorDataNotFound) {
+				return domain.ErrorInvalidInvitation
+			}
+			return u.internal(ctx, "claim invitation", err)
+		}
+
+		if !time.Now().Before(invitation.ExpiresAt) {
+			return domain.ErrorInvalidInvitation
+		}
+
+		user, err = u.repo.CreateUser(ctx, &domain.User{
+			Name:     name,
+			Email:    invitation.Email,
+			Password: hashedPassword,
+			Role:     invitation.Role,
+		})
+		if err != nil {
+			if errors.Is(err, domain.ErrorConflictData) {
+				return err
+			}
+			return u.internal(ctx, "create user", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, u.txError(ctx, err)
+	}
+
+	return u.cacheNewUser(ctx, user)
+}
+
+func (u UserService) requireAdmin(ctx context.Context) error {
+	id, ok := utils.CallerID(ctx)
+	if !ok {
+		return domain.ErrorUnauthenticated
+	}
+
+	caller, err := u.GetUser(ctx, id)
 	if err != nil {
 		if errors.Is(err, domain.ErrorDataNotFound) {
-			return err
+			return domain.ErrorUnauthenticated
 		}
-		return domain.ErrorInternal
+		return err
 	}
 
-	cacheKey := utils.GenerateCacheKey("user", id)
+	if caller.Role != domain.Admin {
+		return domain.ErrorPermissionDenied
+	}
 
-	err = u.cache.Delete(ctx, cacheKey)
+	return nil
+}
+
+// checkPasswordReuse counts the current password in policy.HistorySize.
+func (u UserService) checkPasswordReuse(ctx context.Context, user *domain.User, password string) error {
+	if u.policy.HistorySize <= 0 {
+		return nil
+	}
+
+	hashes, err := u.repo.ListPasswordHistory(ctx, user.ID, uint64(u.policy.HistorySize-1))
+	if err != nil {
+		return u.internal(ctx, "list password history", err)
+	}
+
+	for _, hash := range append([]string{user.Password}, hashes...) {
+		if utils.ComparePassword(password, hash) == nil {
+			return domain.ErrorPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// withExpiry copies user, which may be shared with other requests.
+func (u UserService) withExpiry(user *domain.User) *domain.User {
+	marked := *user
+	marked.PasswordExpired = u.policy.Expired(&marked, time.Now())
+	return &marked
+}
+
+func (u UserService) withExpiries(users []domain.User) []domain.User {
+	if users == nil {
+		return nil
+	}
+
+	marked := make([]domain.User, len(users))
+	for i := range users {
+		marked[i] = *u.withExpiry(&users[i])
+	}
+	return marked
+}
+
+func (u UserService) forgetUser(ctx context.Context, id uint64) error {
+	err := u.cache.Delete(ctx, utils.GenerateCacheKey("user", id))
 	if err != nil {
-		return domain.ErrorInternal
+		return u.internal(ctx, "invalidate user", err)
 	}
 
-	err = u.cache.DeleteByPrefix(ctx, "users:*")
+	_, err = u.cache.Increment(ctx, usersGenerationKey)
 	if err != nil {
-		return domain.ErrorInternal
+		return u.internal(ctx, "bump users generation", err)
 	}
 
-	return u.repo.DeleteUser(ctx, id)
+	return nil
+}
+
+// WatchUsers starts from the latest event when since is zero.
+func (u UserService) WatchUsers(ctx context.Context, since uint64, send func(*domain.UserEvent) error) error {
+	cursor := since
+
+	if cursor == 0 {
+		last, err := u.events.LastUserEventSequence(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return u.internal(ctx, "read last user event", err)
+		}
+		cursor = last
+	}
+
+	var gapSince time.Time
+	for {
+		err := u.events.WaitUserEvents(ctx, cursor)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return u.internal(ctx, "wait user events", err)
+		}
+
+		events, err := u.events.ListUserEvents(ctx, cursor, watchBatchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return u.internal(ctx, "list user events", err)
+		}
+
+		waiting := false
+		for i := range events {
+			if events[i].Sequence != cursor+1 {
+				if gapSince.IsZero() {
+					gapSince = time.Now()
+				}
+				if time.Since(gapSince) < watchGapTimeout {
+					waiting = true
+					break
+				}
+			}
+			gapSince = time.Time{}
+
+			err := send(&events[i])
+			if err != nil {
+				return err
+			}
+			cursor = events[i].Sequence
+		}
+
+		if waiting {
+			select {
+			case <-time.After(watchGapPollInterval):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// txError reports anything but domain errors, such as a failed commit, as
+// domain.ErrorInternal.
+func (u UserService) txError(ctx context.Context, err error) error {
+	for _, domainErr := range []error{
+		domain.ErrorDataNotFound,
+		domain.ErrorConflictData,
+		domain.ErrorNoUpdatedData,
+		domain.ErrorInvalidCredentials,
+		domain.ErrorPasswordReused,
+		domain.ErrorInvalidInvitation,
+		domain.ErrorInternal,
+	} {
+		if errors.Is(err, domainErr) {
+			return domainErr
+		}
+	}
+	return u.internal(ctx, "transaction", err)
+}
+
+// internal logs err, which callers never see.
+func (u UserService) internal(ctx context.Context, op string, err error) error {
+	level.Error(u.log(ctx)).Log("msg", op, "err", err)
+	return domain.ErrorInternal
+}
+
+func (u UserService) log(ctx context.Context) log.Logger {
+	return utils.Logger(ctx, u.logger)
 }
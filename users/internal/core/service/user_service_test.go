@@ -1,7 +1,10 @@
 package service_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"sync"
 
 	"testing"
 	"time"
@@ -11,9 +14,21 @@ import (
 	"github.com/OzkrOssa/radiusx-users/internal/core/service"
 	"github.com/OzkrOssa/radiusx-users/internal/core/utils"
 	"github.com/brianvoe/gofakeit/v7"
+	"github.com/go-kit/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// newTransactor returns a port.Transactor that runs fn directly with the
+// context it was given.
+func newTransactor(t *testing.T) *mocks.Transactor {
+	tx := mocks.NewTransactor(t)
+	tx.On("WithinTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).Maybe()
+	return tx
+}
+
 type registerInput struct {
 	user *domain.User
 }
@@ -46,7 +61,7 @@ func TestUserService_Register(t *testing.T) {
 
 	serializedUser, _ := utils.Serialize(userOutput)
 	cacheKey := utils.GenerateCacheKey("user", userOutput.ID)
-	ttl := time.Duration(0)
+	ttl := mock.MatchedBy(func(ttl time.Duration) bool { return ttl > 0 })
 
 	testCases := []struct {
 		desc     string
@@ -59,7 +74,7 @@ func TestUserService_Register(t *testing.T) {
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
 				repo.On("CreateUser", ctx, userInput).Return(userOutput, nil)
 				cache.On("Set", ctx, cacheKey, serializedUser, ttl).Return(nil)
-				cache.On("DeleteByPrefix", ctx, "users:*").Return(nil)
+				cache.On("Increment", ctx, "users:generation").Return(int64(1), nil)
 			},
 			input: registerInput{user: userInput},
 			expected: expectedOutput{
@@ -102,11 +117,11 @@ func TestUserService_Register(t *testing.T) {
 			},
 		},
 		{
-			desc: "Fail_DeleteCacheByPrefix",
+			desc: "Fail_IncrementGeneration",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
 				repo.On("CreateUser", ctx, userInput).Return(userOutput, nil)
 				cache.On("Set", ctx, cacheKey, serializedUser, ttl).Return(nil)
-				cache.On("DeleteByPrefix", ctx, "users:*").Return(domain.ErrorInternal)
+				cache.On("Increment", ctx, "users:generation").Return(int64(1), domain.ErrorInternal)
 			},
 			input: registerInput{user: userInput},
 			expected: expectedOutput{
@@ -120,9 +135,10 @@ func TestUserService_Register(t *testing.T) {
 		t.Run(tc.desc, func(t *testing.T) {
 			repo := mocks.NewUserRepository(t)
 			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
 			tc.mocks(repo, cache)
 
-			userService := service.NewUserService(repo, cache)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
 
 			user, err := userService.Register(ctx, tc.input.user)
 			assert.Equal(t, tc.expected.err, err, "Error mismatch")
@@ -154,7 +170,7 @@ func TestUserService_GetUser(t *testing.T) {
 
 	cacheKey := utils.GenerateCacheKey("user", id)
 	userSerialized, _ := utils.Serialize(userOutput)
-	ttl := time.Duration(0)
+	ttl := mock.MatchedBy(func(ttl time.Duration) bool { return ttl > 0 })
 
 	testCases := []struct {
 		desc     string
@@ -177,8 +193,8 @@ func TestUserService_GetUser(t *testing.T) {
 			desc: "Success_FromDB",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
 				cache.On("Get", ctx, cacheKey).Return(nil, domain.ErrorDataNotFound)
-				repo.On("GetUserById", ctx, id).Return(userOutput, nil)
-				cache.On("Set", ctx, cacheKey, userSerialized, ttl).Return(nil)
+				repo.On("GetUserById", mock.Anything, id).Return(userOutput, nil)
+				cache.On("Set", mock.Anything, cacheKey, userSerialized, ttl).Return(nil)
 			},
 			input: getUserTestedInput{ID: id},
 			expected: getUserExpectedOutput{
@@ -190,7 +206,19 @@ func TestUserService_GetUser(t *testing.T) {
 			desc: "Fail_NotFound",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
 				cache.On("Get", ctx, cacheKey).Return(nil, domain.ErrorDataNotFound)
-				repo.On("GetUserById", ctx, id).Return(nil, domain.ErrorDataNotFound)
+				repo.On("GetUserById", mock.Anything, id).Return(nil, domain.ErrorDataNotFound)
+				cache.On("Set", mock.Anything, cacheKey, []byte("null"), ttl).Return(nil)
+			},
+			input: getUserTestedInput{ID: id},
+			expected: getUserExpectedOutput{
+				user: nil,
+				err:  domain.ErrorDataNotFound,
+			},
+		},
+		{
+			desc: "Fail_NotFoundFromCache",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, cacheKey).Return([]byte("null"), nil)
 			},
 			input: getUserTestedInput{ID: id},
 			expected: getUserExpectedOutput{
@@ -202,7 +230,7 @@ func TestUserService_GetUser(t *testing.T) {
 			desc: "Fail_InternalError",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
 				cache.On("Get", ctx, cacheKey).Return(nil, domain.ErrorInternal)
-				repo.On("GetUserById", ctx, id).Return(nil, domain.ErrorInternal)
+				repo.On("GetUserById", mock.Anything, id).Return(nil, domain.ErrorInternal)
 			},
 			input: getUserTestedInput{ID: id},
 			expected: getUserExpectedOutput{
@@ -214,8 +242,8 @@ func TestUserService_GetUser(t *testing.T) {
 			desc: "Fail_SetCache",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
 				cache.On("Get", ctx, cacheKey).Return(nil, domain.ErrorDataNotFound)
-				repo.On("GetUserById", ctx, id).Return(userOutput, nil)
-				cache.On("Set", ctx, cacheKey, userSerialized, ttl).Return(domain.ErrorInternal)
+				repo.On("GetUserById", mock.Anything, id).Return(userOutput, nil)
+				cache.On("Set", mock.Anything, cacheKey, userSerialized, ttl).Return(domain.ErrorInternal)
 			},
 			input: getUserTestedInput{ID: id},
 			expected: getUserExpectedOutput{
@@ -240,9 +268,10 @@ func TestUserService_GetUser(t *testing.T) {
 		t.Run(tc.desc, func(t *testing.T) {
 			repo := mocks.NewUserRepository(t)
 			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
 			tc.mocks(repo, cache)
 
-			userService := service.NewUserService(repo, cache)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
 
 			user, err := userService.GetUser(ctx, id)
 			assert.Equal(t, tc.expected.err, err, "Error mismatch")
@@ -251,6 +280,110 @@ func TestUserService_GetUser(t *testing.T) {
 	}
 }
 
+func TestUserService_GetUser_CoalescesMisses(t *testing.T) {
+	ctx := context.Background()
+	id := gofakeit.Uint64()
+	callers := 10
+
+	userOutput := &domain.User{
+		ID:    id,
+		Email: gofakeit.Email(),
+		Name:  gofakeit.Name(),
+	}
+
+	cacheKey := utils.GenerateCacheKey("user", id)
+	userSerialized, _ := utils.Serialize(userOutput)
+
+	repo := mocks.NewUserRepository(t)
+	cache := mocks.NewCacheRepository(t)
+	events := mocks.NewUserEventRepository(t)
+
+	var ready sync.WaitGroup
+	ready.Add(callers)
+
+	cache.On("Get", ctx, cacheKey).Run(func(mock.Arguments) {
+		ready.Done()
+	}).Return(nil, domain.ErrorDataNotFound)
+	repo.On("GetUserById", mock.Anything, id).Run(func(mock.Arguments) {
+		// Hold the first lookup until every caller has missed the cache and
+		// had time to join the in-flight call.
+		ready.Wait()
+		time.Sleep(50 * time.Millisecond)
+	}).Return(userOutput, nil).Once()
+	cache.On("Set", mock.Anything, cacheKey, userSerialized, mock.Anything).Return(nil).Once()
+
+	userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := userService.GetUser(ctx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, userOutput, user)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUserService_GetUser_SharedLookupOutlivesCaller(t *testing.T) {
+	id := gofakeit.Uint64()
+
+	userOutput := &domain.User{
+		ID:    id,
+		Email: gofakeit.Email(),
+		Name:  gofakeit.Name(),
+	}
+
+	cacheKey := utils.GenerateCacheKey("user", id)
+
+	repo := mocks.NewUserRepository(t)
+	cache := mocks.NewCacheRepository(t)
+	events := mocks.NewUserEventRepository(t)
+
+	missed := make(chan struct{}, 2)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	cache.On("Get", mock.Anything, cacheKey).Run(func(mock.Arguments) {
+		missed <- struct{}{}
+	}).Return(nil, domain.ErrorDataNotFound)
+	repo.On("GetUserById", mock.Anything, id).Run(func(args mock.Arguments) {
+		close(started)
+		<-release
+		assert.NoError(t, args.Get(0).(context.Context).Err(), "Lookup cancelled with the caller that started it")
+	}).Return(userOutput, nil).Once()
+	cache.On("Set", mock.Anything, cacheKey, mock.Anything, mock.Anything).Return(nil).Once()
+
+	userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := userService.GetUser(first, id)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan *domain.User, 1)
+	go func() {
+		user, err := userService.GetUser(context.Background(), id)
+		assert.NoError(t, err)
+		second <- user
+	}()
+	<-missed
+	<-missed
+	// Give the second caller time to join the in-flight lookup.
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-firstErr, "Cancelled caller kept waiting")
+
+	close(release)
+	assert.Equal(t, userOutput, <-second)
+}
+
 type listUsersTestedInput struct {
 	skip  uint64
 	limit uint64
@@ -264,7 +397,8 @@ type listUsersExpectedOutput struct {
 func TestUserService_ListUsers(t *testing.T) {
 	ctx := context.Background()
 	skip, limit := gofakeit.Uint64(), gofakeit.Uint64()
-	params := utils.GenerateCacheKeyParams(skip, limit)
+	generationKey := utils.GenerateCacheKey("users", "generation")
+	params := utils.GenerateCacheKeyParams("7", skip, limit)
 	cacheKey := utils.GenerateCacheKey("users", params)
 
 	var users []domain.User
@@ -282,7 +416,7 @@ func TestUserService_ListUsers(t *testing.T) {
 	}
 
 	usersSerialized, _ := utils.Serialize(users)
-	ttl := time.Duration(0)
+	ttl := mock.MatchedBy(func(ttl time.Duration) bool { return ttl > 0 })
 
 	testCases := []struct {
 		desc     string
@@ -293,6 +427,7 @@ func TestUserService_ListUsers(t *testing.T) {
 		{
 			desc: "Success_FromCache",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, generationKey).Return([]byte("7"), nil)
 				cache.On("Get", ctx, cacheKey).Return(usersSerialized, nil)
 			},
 			input: listUsersTestedInput{
@@ -307,9 +442,40 @@ func TestUserService_ListUsers(t *testing.T) {
 		{
 			desc: "Success_FromDB",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, generationKey).Return([]byte("7"), nil)
 				cache.On("Get", ctx, cacheKey).Return(nil, domain.ErrorDataNotFound)
+				repo.On("ListUsers", mock.Anything, skip, limit).Return(users, nil)
+				cache.On("Set", mock.Anything, cacheKey, usersSerialized, ttl).Return(nil)
+			},
+			input: listUsersTestedInput{
+				skip:  skip,
+				limit: limit,
+			},
+			expected: listUsersExpectedOutput{
+				users: users,
+				err:   nil,
+			},
+		},
+		{
+			desc: "Success_CacheUnavailable",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, generationKey).Return(nil, errors.New("connection refused"))
 				repo.On("ListUsers", ctx, skip, limit).Return(users, nil)
-				cache.On("Set", ctx, cacheKey, usersSerialized, ttl).Return(nil)
+			},
+			input: listUsersTestedInput{
+				skip:  skip,
+				limit: limit,
+			},
+			expected: listUsersExpectedOutput{
+				users: users,
+				err:   nil,
+			},
+		},
+		{
+			desc: "Success_WithoutGeneration",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, generationKey).Return(nil, domain.ErrorDataNotFound)
+				cache.On("Get", ctx, utils.GenerateCacheKey("users", utils.GenerateCacheKeyParams("0", skip, limit))).Return(usersSerialized, nil)
 			},
 			input: listUsersTestedInput{
 				skip:  skip,
@@ -323,8 +489,9 @@ func TestUserService_ListUsers(t *testing.T) {
 		{
 			desc: "Fail_InternalError",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, generationKey).Return([]byte("7"), nil)
 				cache.On("Get", ctx, cacheKey).Return(nil, domain.ErrorInternal)
-				repo.On("ListUsers", ctx, skip, limit).Return(nil, domain.ErrorInternal)
+				repo.On("ListUsers", mock.Anything, skip, limit).Return(nil, domain.ErrorInternal)
 			},
 			input: listUsersTestedInput{
 				skip:  skip,
@@ -338,6 +505,7 @@ func TestUserService_ListUsers(t *testing.T) {
 		{
 			desc: "Fail_Deserialize",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, generationKey).Return([]byte("7"), nil)
 				cache.On("Get", ctx, cacheKey).Return([]byte("invalid"), nil)
 			},
 			input: listUsersTestedInput{
@@ -352,9 +520,10 @@ func TestUserService_ListUsers(t *testing.T) {
 		{
 			desc: "Fail_SetCache",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, generationKey).Return([]byte("7"), nil)
 				cache.On("Get", ctx, cacheKey).Return(nil, domain.ErrorDataNotFound)
-				repo.On("ListUsers", ctx, skip, limit).Return(users, nil)
-				cache.On("Set", ctx, cacheKey, usersSerialized, ttl).Return(domain.ErrorInternal)
+				repo.On("ListUsers", mock.Anything, skip, limit).Return(users, nil)
+				cache.On("Set", mock.Anything, cacheKey, usersSerialized, ttl).Return(domain.ErrorInternal)
 			},
 			input: listUsersTestedInput{
 				skip:  skip,
@@ -371,9 +540,10 @@ func TestUserService_ListUsers(t *testing.T) {
 		t.Run(tc.desc, func(t *testing.T) {
 			repo := mocks.NewUserRepository(t)
 			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
 			tc.mocks(repo, cache)
 
-			userService := service.NewUserService(repo, cache)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
 
 			users, err := userService.ListUsers(ctx, tc.input.skip, tc.input.limit)
 			assert.Equal(t, tc.expected.err, err, "Error mismatch")
@@ -415,7 +585,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 
 	cacheKey := utils.GenerateCacheKey("user", id)
 	userSerialized, _ := utils.Serialize(userOutput)
-	ttl := time.Duration(0)
+	ttl := mock.MatchedBy(func(ttl time.Duration) bool { return ttl > 0 })
 
 	testCases := []struct {
 		desc     string
@@ -426,11 +596,11 @@ func TestUserService_UpdateUser(t *testing.T) {
 		{
 			desc: "Success",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(existingUser, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existingUser, nil)
 				repo.On("UpdateUser", ctx, userInput).Return(userOutput, nil)
 				cache.On("Delete", ctx, cacheKey).Return(nil)
 				cache.On("Set", ctx, cacheKey, userSerialized, ttl).Return(nil)
-				cache.On("DeleteByPrefix", ctx, "users:*").Return(nil)
+				cache.On("Increment", ctx, "users:generation").Return(int64(1), nil)
 
 			},
 			input: updateUserTestedInput{
@@ -444,7 +614,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		{
 			desc: "Fail_NotFound",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(nil, domain.ErrorDataNotFound)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(nil, domain.ErrorDataNotFound)
 
 			},
 			input: updateUserTestedInput{
@@ -458,7 +628,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		{
 			desc: "Fail_InternalErrorGetById",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(nil, domain.ErrorInternal)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(nil, domain.ErrorInternal)
 
 			},
 			input: updateUserTestedInput{
@@ -472,7 +642,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		{
 			desc: "Fail_EmptyData",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(existingUser, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existingUser, nil)
 			},
 			input: updateUserTestedInput{
 				user: &domain.User{
@@ -487,7 +657,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		{
 			desc: "Fail_SameData",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(existingUser, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existingUser, nil)
 			},
 			input: updateUserTestedInput{
 				user: existingUser,
@@ -500,7 +670,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		{
 			desc: "Fail_DuplicateData",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(existingUser, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existingUser, nil)
 				repo.On("UpdateUser", ctx, userInput).Return(nil, domain.ErrorConflictData)
 			},
 			input: updateUserTestedInput{
@@ -514,7 +684,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		{
 			desc: "Fail_InternalErrorUpdate",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(existingUser, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existingUser, nil)
 				repo.On("UpdateUser", ctx, userInput).Return(nil, domain.ErrorInternal)
 			},
 			input: updateUserTestedInput{
@@ -528,7 +698,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		{
 			desc: "Fail_DeleteCache",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(existingUser, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existingUser, nil)
 				repo.On("UpdateUser", ctx, userInput).Return(userOutput, nil)
 				cache.On("Delete", ctx, cacheKey).Return(domain.ErrorInternal)
 			},
@@ -543,7 +713,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		{
 			desc: "Fail_SetCache",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(existingUser, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existingUser, nil)
 				repo.On("UpdateUser", ctx, userInput).Return(userOutput, nil)
 				cache.On("Delete", ctx, cacheKey).Return(nil)
 				cache.On("Set", ctx, cacheKey, userSerialized, ttl).Return(domain.ErrorInternal)
@@ -557,13 +727,13 @@ func TestUserService_UpdateUser(t *testing.T) {
 			},
 		},
 		{
-			desc: "Fail_DeleteByPrefix",
+			desc: "Fail_IncrementGeneration",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(existingUser, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existingUser, nil)
 				repo.On("UpdateUser", ctx, userInput).Return(userOutput, nil)
 				cache.On("Delete", ctx, cacheKey).Return(nil)
 				cache.On("Set", ctx, cacheKey, userSerialized, ttl).Return(nil)
-				cache.On("DeleteByPrefix", ctx, "users:*").Return(domain.ErrorInternal)
+				cache.On("Increment", ctx, "users:generation").Return(int64(1), domain.ErrorInternal)
 			},
 			input: updateUserTestedInput{
 				user: userInput,
@@ -579,8 +749,9 @@ func TestUserService_UpdateUser(t *testing.T) {
 		t.Run(tc.desc, func(t *testing.T) {
 			repo := mocks.NewUserRepository(t)
 			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
 			tc.mocks(repo, cache)
-			userService := service.NewUserService(repo, cache)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
 
 			user, err := userService.UpdateUser(ctx, tc.input.user)
 
@@ -609,10 +780,10 @@ func TestUserService_DeleteUser(t *testing.T) {
 		{
 			desc: "Success",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(&domain.User{}, nil)
-				cache.On("Delete", ctx, cacheKey).Return(nil)
-				cache.On("DeleteByPrefix", ctx, "users:*").Return(nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(&domain.User{}, nil)
 				repo.On("DeleteUser", ctx, id).Return(nil)
+				cache.On("Delete", ctx, cacheKey).Return(nil)
+				cache.On("Increment", ctx, "users:generation").Return(int64(1), nil)
 			},
 			input: id,
 			expected: userDeleteExpectedOutput{
@@ -622,7 +793,7 @@ func TestUserService_DeleteUser(t *testing.T) {
 		{
 			desc: "Fail_NotFound",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(nil, domain.ErrorDataNotFound)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(nil, domain.ErrorDataNotFound)
 			},
 			input: id,
 			expected: userDeleteExpectedOutput{
@@ -632,7 +803,7 @@ func TestUserService_DeleteUser(t *testing.T) {
 		{
 			desc: "Fail_InternalErrorGetByID",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(nil, domain.ErrorInternal)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(nil, domain.ErrorInternal)
 			},
 			input: id,
 			expected: userDeleteExpectedOutput{
@@ -642,7 +813,8 @@ func TestUserService_DeleteUser(t *testing.T) {
 		{
 			desc: "Fail_DeleteCache",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(&domain.User{}, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(&domain.User{}, nil)
+				repo.On("DeleteUser", ctx, id).Return(nil)
 				cache.On("Delete", ctx, cacheKey).Return(domain.ErrorInternal)
 			},
 			input: id,
@@ -651,11 +823,12 @@ func TestUserService_DeleteUser(t *testing.T) {
 			},
 		},
 		{
-			desc: "Fail_DeleteByPrefix",
+			desc: "Fail_IncrementGeneration",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(&domain.User{}, nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(&domain.User{}, nil)
+				repo.On("DeleteUser", ctx, id).Return(nil)
 				cache.On("Delete", ctx, cacheKey).Return(nil)
-				cache.On("DeleteByPrefix", ctx, "users:*").Return(domain.ErrorInternal)
+				cache.On("Increment", ctx, "users:generation").Return(int64(1), domain.ErrorInternal)
 			},
 			input: id,
 			expected: userDeleteExpectedOutput{
@@ -665,9 +838,7 @@ func TestUserService_DeleteUser(t *testing.T) {
 		{
 			desc: "Fail_InternalErrorDelete",
 			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
-				repo.On("GetUserById", ctx, id).Return(&domain.User{}, nil)
-				cache.On("Delete", ctx, cacheKey).Return(nil)
-				cache.On("DeleteByPrefix", ctx, "users:*").Return(nil)
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(&domain.User{}, nil)
 				repo.On("DeleteUser", ctx, id).Return(domain.ErrorInternal)
 			},
 			input: id,
@@ -680,8 +851,9 @@ func TestUserService_DeleteUser(t *testing.T) {
 		t.Run(tc.desc, func(t *testing.T) {
 			repo := mocks.NewUserRepository(t)
 			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
 			tc.mocks(repo, cache)
-			userService := service.NewUserService(repo, cache)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
 
 			err := userService.DeleteUser(ctx, tc.input)
 
@@ -690,3 +862,739 @@ func TestUserService_DeleteUser(t *testing.T) {
 	}
 
 }
+
+func TestUserService_DeleteUser_CommitFailure(t *testing.T) {
+	// The cause hidden behind ErrorInternal goes to the request logger.
+	var logs bytes.Buffer
+	ctx := utils.WithLogger(context.Background(), log.NewLogfmtLogger(&logs))
+	id := gofakeit.Uint64()
+
+	repo := mocks.NewUserRepository(t)
+	cache := mocks.NewCacheRepository(t)
+	events := mocks.NewUserEventRepository(t)
+	tx := mocks.NewTransactor(t)
+
+	repo.On("GetUserByIdForUpdate", ctx, id).Return(&domain.User{}, nil)
+	repo.On("DeleteUser", ctx, id).Return(nil)
+	tx.On("WithinTx", ctx, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return errors.New("commit failed")
+	})
+
+	userService := service.NewUserService(repo, cache, events, tx, domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+	err := userService.DeleteUser(ctx, id)
+
+	assert.Equal(t, domain.ErrorInternal, err, "Error mismatch")
+	assert.Contains(t, logs.String(), `err="commit failed"`, "Cause not logged")
+	cache.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+type watchUsersExpectedOutput struct {
+	sent []uint64
+	err  error
+}
+
+func TestUserService_WatchUsers(t *testing.T) {
+	user := domain.User{
+		ID:    gofakeit.Uint64(),
+		Name:  gofakeit.Name(),
+		Email: gofakeit.Email(),
+	}
+
+	events := []domain.UserEvent{
+		{Sequence: 11, Type: domain.UserCreated, User: user},
+		{Sequence: 12, Type: domain.UserUpdated, User: user},
+	}
+
+	sendErr := errors.New("stream closed")
+
+	testCases := []struct {
+		desc     string
+		mocks    func(events *mocks.UserEventRepository, cancel context.CancelFunc)
+		since    uint64
+		send     error
+		expected watchUsersExpectedOutput
+	}{
+		{
+			desc: "Success_Resume",
+			mocks: func(repo *mocks.UserEventRepository, cancel context.CancelFunc) {
+				repo.On("WaitUserEvents", mock.Anything, uint64(10)).Return(nil).Once()
+				repo.On("ListUserEvents", mock.Anything, uint64(10), uint64(100)).Return(events, nil).Once()
+				repo.On("WaitUserEvents", mock.Anything, uint64(12)).Run(func(mock.Arguments) { cancel() }).Return(context.Canceled).Once()
+			},
+			since: 10,
+			expected: watchUsersExpectedOutput{
+				sent: []uint64{11, 12},
+				err:  nil,
+			},
+		},
+		{
+			desc: "Success_GapFilled",
+			mocks: func(repo *mocks.UserEventRepository, cancel context.CancelFunc) {
+				repo.On("WaitUserEvents", mock.Anything, uint64(10)).Return(nil).Twice()
+				repo.On("ListUserEvents", mock.Anything, uint64(10), uint64(100)).Return(events[1:], nil).Once()
+				repo.On("ListUserEvents", mock.Anything, uint64(10), uint64(100)).Return(events, nil).Once()
+				repo.On("WaitUserEvents", mock.Anything, uint64(12)).Run(func(mock.Arguments) { cancel() }).Return(context.Canceled).Once()
+			},
+			since: 10,
+			expected: watchUsersExpectedOutput{
+				sent: []uint64{11, 12},
+				err:  nil,
+			},
+		},
+		{
+			desc: "Success_FromHead",
+			mocks: func(repo *mocks.UserEventRepository, cancel context.CancelFunc) {
+				repo.On("LastUserEventSequence", mock.Anything).Return(uint64(12), nil).Once()
+				repo.On("WaitUserEvents", mock.Anything, uint64(12)).Run(func(mock.Arguments) { cancel() }).Return(context.Canceled).Once()
+			},
+			since: 0,
+			expected: watchUsersExpectedOutput{
+				sent: nil,
+				err:  nil,
+			},
+		},
+		{
+			desc: "Fail_LastSequence",
+			mocks: func(repo *mocks.UserEventRepository, cancel context.CancelFunc) {
+				repo.On("LastUserEventSequence", mock.Anything).Return(uint64(0), domain.ErrorInternal).Once()
+			},
+			since: 0,
+			expected: watchUsersExpectedOutput{
+				sent: nil,
+				err:  domain.ErrorInternal,
+			},
+		},
+		{
+			desc: "Fail_ListEvents",
+			mocks: func(repo *mocks.UserEventRepository, cancel context.CancelFunc) {
+				repo.On("WaitUserEvents", mock.Anything, uint64(10)).Return(nil).Once()
+				repo.On("ListUserEvents", mock.Anything, uint64(10), uint64(100)).Return(nil, domain.ErrorInternal).Once()
+			},
+			since: 10,
+			expected: watchUsersExpectedOutput{
+				sent: nil,
+				err:  domain.ErrorInternal,
+			},
+		},
+		{
+			desc: "Fail_Send",
+			mocks: func(repo *mocks.UserEventRepository, cancel context.CancelFunc) {
+				repo.On("WaitUserEvents", mock.Anything, uint64(10)).Return(nil).Once()
+				repo.On("ListUserEvents", mock.Anything, uint64(10), uint64(100)).Return(events, nil).Once()
+			},
+			since: 10,
+			send:  sendErr,
+			expected: watchUsersExpectedOutput{
+				sent: []uint64{11},
+				err:  sendErr,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			repo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
+			tc.mocks(events, cancel)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+			var sent []uint64
+			err := userService.WatchUsers(ctx, tc.since, func(event *domain.UserEvent) error {
+				sent = append(sent, event.Sequence)
+				return tc.send
+			})
+
+			assert.Equal(t, tc.expected.err, err, "Error mismatch")
+			assert.Equal(t, tc.expected.sent, sent, "Events mismatch")
+		})
+	}
+}
+
+func TestUserService_GetMe(t *testing.T) {
+	id := gofakeit.Uint64()
+	user := &domain.User{ID: id, Name: gofakeit.Name(), Email: gofakeit.Email(), Role: domain.Agent}
+	serializedUser, _ := utils.Serialize(user)
+
+	testCases := []struct {
+		desc     string
+		ctx      context.Context
+		mocks    func(ctx context.Context, cache *mocks.CacheRepository)
+		expected getUserExpectedOutput
+	}{
+		{
+			desc: "Success",
+			ctx:  utils.WithCallerID(context.Background(), id),
+			mocks: func(ctx context.Context, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, utils.GenerateCacheKey("user", id)).Return(serializedUser, nil)
+			},
+			expected: getUserExpectedOutput{user: user},
+		},
+		{
+			desc:     "Fail_Anonymous",
+			ctx:      context.Background(),
+			mocks:    func(ctx context.Context, cache *mocks.CacheRepository) {},
+			expected: getUserExpectedOutput{err: domain.ErrorUnauthenticated},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
+			tc.mocks(tc.ctx, cache)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+			user, err := userService.GetMe(tc.ctx)
+
+			assert.Equal(t, tc.expected.err, err, "Error mismatch")
+			assert.Equal(t, tc.expected.user, user, "User mismatch")
+		})
+	}
+}
+
+func TestUserService_UpdateMe(t *testing.T) {
+	id := gofakeit.Uint64()
+	ctx := utils.WithCallerID(context.Background(), id)
+	name := gofakeit.Name()
+
+	repo := mocks.NewUserRepository(t)
+	cache := mocks.NewCacheRepository(t)
+	events := mocks.NewUserEventRepository(t)
+
+	// Role and password are dropped before reaching the repository.
+	expected := &domain.User{ID: id, Name: name}
+	repo.On("GetUserByIdForUpdate", ctx, id).Return(&domain.User{ID: id, Name: "old", Role: domain.Reader}, nil)
+	repo.On("UpdateUser", ctx, expected).Return(expected, nil)
+	cache.On("Delete", ctx, utils.GenerateCacheKey("user", id)).Return(nil)
+	cache.On("Set", ctx, utils.GenerateCacheKey("user", id), mock.Anything, mock.Anything).Return(nil)
+	cache.On("Increment", ctx, "users:generation").Return(int64(1), nil)
+
+	userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+	user, err := userService.UpdateMe(ctx, &domain.User{ID: id + 1, Name: name, Password: "newpassword1", Role: domain.Admin})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, user, "User mismatch")
+
+	_, err = userService.UpdateMe(context.Background(), &domain.User{Name: name})
+	assert.Equal(t, domain.ErrorUnauthenticated, err, "Anonymous caller accepted")
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	id := gofakeit.Uint64()
+	ctx := utils.WithCallerID(context.Background(), id)
+	cacheKey := utils.GenerateCacheKey("user", id)
+
+	hashedPassword, err := utils.HashPassword("currentpass1")
+	assert.NoError(t, err)
+	existing := &domain.User{ID: id, Password: hashedPassword}
+
+	testCases := []struct {
+		desc            string
+		ctx             context.Context
+		currentPassword string
+		newPassword     string
+		mocks           func(repo *mocks.UserRepository, cache *mocks.CacheRepository)
+		expected        error
+	}{
+		{
+			desc:            "Success",
+			ctx:             ctx,
+			currentPassword: "currentpass1",
+			newPassword:     "newpassword1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existing, nil)
+				repo.On("UpdateUser", ctx, mock.MatchedBy(func(user *domain.User) bool {
+					return user.ID == id && user.Role == "" && utils.ComparePassword("newpassword1", user.Password) == nil
+				})).Return(&domain.User{}, nil)
+				cache.On("Delete", ctx, cacheKey).Return(nil)
+				cache.On("Increment", ctx, "users:generation").Return(int64(1), nil)
+			},
+		},
+		{
+			desc:            "Fail_WrongCurrentPassword",
+			ctx:             ctx,
+			currentPassword: "wrongpass1",
+			newPassword:     "newpassword1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existing, nil)
+			},
+			expected: domain.ErrorInvalidCredentials,
+		},
+		{
+			desc:            "Fail_SamePassword",
+			ctx:             ctx,
+			currentPassword: "currentpass1",
+			newPassword:     "currentpass1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(existing, nil)
+			},
+			expected: domain.ErrorNoUpdatedData,
+		},
+		{
+			desc:            "Fail_NotFound",
+			ctx:             ctx,
+			currentPassword: "currentpass1",
+			newPassword:     "newpassword1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByIdForUpdate", ctx, id).Return(nil, domain.ErrorDataNotFound)
+			},
+			expected: domain.ErrorDataNotFound,
+		},
+		{
+			desc:            "Fail_Anonymous",
+			ctx:             context.Background(),
+			currentPassword: "currentpass1",
+			newPassword:     "newpassword1",
+			mocks:           func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {},
+			expected:        domain.ErrorUnauthenticated,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
+			tc.mocks(repo, cache)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+			err := userService.ChangePassword(tc.ctx, tc.currentPassword, tc.newPassword)
+
+			assert.Equal(t, tc.expected, err, "Error mismatch")
+		})
+	}
+}
+
+func TestUserService_UpdateUser_PasswordReuse(t *testing.T) {
+	ctx := context.Background()
+	id := gofakeit.Uint64()
+	policy := domain.PasswordPolicy{HistorySize: 3}
+
+	currentHash, err := utils.HashPassword("currentpass1")
+	assert.NoError(t, err)
+	previousHash, err := utils.HashPassword("previouspass1")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		password string
+		mocks    func(repo *mocks.UserRepository, cache *mocks.CacheRepository)
+		expected error
+	}{
+		{
+			desc:     "Success_NewPassword",
+			password: "newpassword1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("UpdateUser", ctx, mock.Anything).Return(&domain.User{}, nil)
+				cache.On("Delete", ctx, mock.Anything).Return(nil)
+				cache.On("Set", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				cache.On("Increment", ctx, "users:generation").Return(int64(1), nil)
+			},
+		},
+		{
+			desc:     "Fail_CurrentPassword",
+			password: "currentpass1",
+			mocks:    func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {},
+			expected: domain.ErrorPasswordReused,
+		},
+		{
+			desc:     "Fail_PreviousPassword",
+			password: "previouspass1",
+			mocks:    func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {},
+			expected: domain.ErrorPasswordReused,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
+			repo.On("GetUserByIdForUpdate", ctx, id).Return(&domain.User{ID: id, Password: currentHash}, nil)
+			repo.On("ListPasswordHistory", ctx, id, uint64(2)).Return([]string{previousHash}, nil)
+			tc.mocks(repo, cache)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), policy, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+			_, err := userService.UpdateUser(ctx, &domain.User{ID: id, Password: tc.password})
+
+			assert.Equal(t, tc.expected, err, "Error mismatch")
+		})
+	}
+}
+
+func TestUserService_GetUser_PasswordExpired(t *testing.T) {
+	ctx := context.Background()
+	policy := domain.PasswordPolicy{MaxAge: map[domain.Role]time.Duration{domain.Admin: time.Hour}}
+	changedAt := time.Now().Add(-2 * time.Hour)
+
+	testCases := []struct {
+		desc     string
+		user     *domain.User
+		expected bool
+	}{
+		{desc: "Expired", user: &domain.User{ID: 1, Role: domain.Admin, PasswordChangedAt: changedAt}, expected: true},
+		{desc: "RoleWithoutMaxAge", user: &domain.User{ID: 2, Role: domain.Reader, PasswordChangedAt: changedAt}},
+		{desc: "Recent", user: &domain.User{ID: 3, Role: domain.Admin, PasswordChangedAt: time.Now()}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
+
+			serializedUser, err := utils.Serialize(tc.user)
+			assert.NoError(t, err)
+			cache.On("Get", ctx, utils.GenerateCacheKey("user", tc.user.ID)).Return(serializedUser, nil)
+
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), policy, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+			user, err := userService.GetUser(ctx, tc.user.ID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, user.PasswordExpired, "Expiry mismatch")
+		})
+	}
+}
+
+func TestUserService_VerifyCredentials(t *testing.T) {
+	ctx := context.Background()
+	id := gofakeit.Uint64()
+	email := gofakeit.Email()
+	origin := domain.LoginOrigin{IPAddress: gofakeit.IPv4Address(), UserAgent: gofakeit.UserAgent()}
+	loggedInAt := time.Now()
+
+	hashedPassword, err := utils.HashPassword("secretpass1")
+	assert.NoError(t, err)
+
+	// existing returns a fresh user, which the service fills in.
+	existing := func() *domain.User {
+		return &domain.User{ID: id, Email: email, Password: hashedPassword, Role: domain.Agent}
+	}
+
+	recorded := func(event domain.LoginEvent) interface{} {
+		return mock.MatchedBy(func(actual *domain.LoginEvent) bool {
+			return *actual == event
+		})
+	}
+
+	testCases := []struct {
+		desc     string
+		email    string
+		password string
+		mocks    func(repo *mocks.UserRepository, cache *mocks.CacheRepository)
+		expected getUserExpectedOutput
+	}{
+		{
+			desc:     "Success",
+			email:    email,
+			password: "secretpass1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByEmail", ctx, email).Return(existing(), nil)
+				repo.On("RecordLogin", ctx, recorded(domain.LoginEvent{UserID: id, Email: email, Success: true, LoginOrigin: origin})).
+					Return(&domain.LoginEvent{ID: 1, UserID: id, Success: true, CreatedAt: loggedInAt}, nil)
+				cache.On("Delete", ctx, utils.GenerateCacheKey("user", id)).Return(nil)
+			},
+			expected: getUserExpectedOutput{user: &domain.User{ID: id, Email: email, Password: hashedPassword, Role: domain.Agent, LastLoginAt: loggedInAt}},
+		},
+		{
+			desc:     "Success_CacheUnavailable",
+			email:    email,
+			password: "secretpass1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByEmail", ctx, email).Return(existing(), nil)
+				repo.On("RecordLogin", ctx, mock.Anything).
+					Return(&domain.LoginEvent{ID: 1, UserID: id, Success: true, CreatedAt: loggedInAt}, nil)
+				cache.On("Delete", ctx, utils.GenerateCacheKey("user", id)).Return(errors.New("connection refused"))
+			},
+			expected: getUserExpectedOutput{user: &domain.User{ID: id, Email: email, Password: hashedPassword, Role: domain.Agent, LastLoginAt: loggedInAt}},
+		},
+		{
+			desc:     "Fail_WrongPassword",
+			email:    email,
+			password: "wrongpass1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByEmail", ctx, email).Return(existing(), nil)
+				repo.On("RecordLogin", ctx, recorded(domain.LoginEvent{UserID: id, Email: email, Reason: domain.LoginFailureWrongPassword, LoginOrigin: origin})).
+					Return(&domain.LoginEvent{ID: 1}, nil)
+			},
+			expected: getUserExpectedOutput{err: domain.ErrorInvalidCredentials},
+		},
+		{
+			desc:     "Fail_UnknownEmail",
+			email:    "nobody@example.com",
+			password: "secretpass1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByEmail", ctx, "nobody@example.com").Return(nil, domain.ErrorDataNotFound)
+				repo.On("RecordLogin", ctx, recorded(domain.LoginEvent{Email: "nobody@example.com", Reason: domain.LoginFailureUnknownEmail, LoginOrigin: origin})).
+					Return(&domain.LoginEvent{ID: 1}, nil)
+			},
+			expected: getUserExpectedOutput{err: domain.ErrorInvalidCredentials},
+		},
+		{
+			desc:     "Fail_DeletedMeanwhile",
+			email:    email,
+			password: "secretpass1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByEmail", ctx, email).Return(existing(), nil)
+				repo.On("RecordLogin", ctx, mock.Anything).Return(nil, domain.ErrorDataNotFound)
+			},
+			expected: getUserExpectedOutput{err: domain.ErrorInvalidCredentials},
+		},
+		{
+			desc:     "Fail_RecordLogin",
+			email:    email,
+			password: "secretpass1",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("GetUserByEmail", ctx, email).Return(existing(), nil)
+				repo.On("RecordLogin", ctx, mock.Anything).Return(nil, errors.New("database down"))
+			},
+			expected: getUserExpectedOutput{err: domain.ErrorInternal},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheRepository(t)
+			events := mocks.NewUserEventRepository(t)
+			tc.mocks(repo, cache)
+			userService := service.NewUserService(repo, cache, events, newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+			user, err := userService.VerifyCredentials(ctx, tc.email, tc.password, origin)
+
+			assert.Equal(t, tc.expected.err, err, "Error mismatch")
+			assert.Equal(t, tc.expected.user, user, "User mismatch")
+		})
+	}
+}
+
+func TestUserService_ListLoginEvents(t *testing.T) {
+	callerID := gofakeit.Uint64()
+	ctx := utils.WithCallerID(context.Background(), callerID)
+	callerKey := utils.GenerateCacheKey("user", callerID)
+
+	cachedCaller := func(role domain.Role) []byte {
+		serialized, _ := utils.Serialize(&domain.User{ID: callerID, Role: role})
+		return serialized
+	}
+
+	events := []domain.LoginEvent{{ID: 2, UserID: 7, Success: true}, {ID: 1, UserID: 7, Reason: domain.LoginFailureWrongPassword}}
+
+	testCases := []struct {
+		desc     string
+		ctx      context.Context
+		mocks    func(repo *mocks.UserRepository, cache *mocks.CacheRepository)
+		expected []domain.LoginEvent
+		err      error
+	}{
+		{
+			desc: "Success",
+			ctx:  ctx,
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, callerKey).Return(cachedCaller(domain.Admin), nil)
+				repo.On("ListLoginEvents", ctx, uint64(7), uint64(1), uint64(10)).Return(events, nil)
+			},
+			expected: events,
+		},
+		{
+			desc: "Fail_NotAdmin",
+			ctx:  ctx,
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, callerKey).Return(cachedCaller(domain.Agent), nil)
+			},
+			err: domain.ErrorPermissionDenied,
+		},
+		{
+			desc: "Fail_CallerDeleted",
+			ctx:  ctx,
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, callerKey).Return([]byte("null"), nil)
+			},
+			err: domain.ErrorUnauthenticated,
+		},
+		{
+			desc:  "Fail_Anonymous",
+			ctx:   context.Background(),
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {},
+			err:   domain.ErrorUnauthenticated,
+		},
+		{
+			desc: "Fail_Repository",
+			ctx:  ctx,
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, callerKey).Return(cachedCaller(domain.Admin), nil)
+				repo.On("ListLoginEvents", ctx, uint64(7), uint64(1), uint64(10)).Return(nil, errors.New("database down"))
+			},
+			err: domain.ErrorInternal,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheRepository(t)
+			tc.mocks(repo, cache)
+			userService := service.NewUserService(repo, cache, mocks.NewUserEventRepository(t), newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{}, log.NewNopLogger())
+
+			actual, err := userService.ListLoginEvents(tc.ctx, 7, 1, 10)
+
+			assert.Equal(t, tc.err, err, "Error mismatch")
+			assert.Equal(t, tc.expected, actual, "Events mismatch")
+		})
+	}
+}
+
+func TestUserService_Register_InviteOnly(t *testing.T) {
+	repo := mocks.NewUserRepository(t)
+	cache := mocks.NewCacheRepository(t)
+	userService := service.NewUserService(repo, cache, mocks.NewUserEventRepository(t), newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{InviteOnly: true}, log.NewNopLogger())
+
+	user, err := userService.Register(context.Background(), &domain.User{Name: gofakeit.Name(), Email: gofakeit.Email(), Password: "secretpass1"})
+
+	assert.Equal(t, domain.ErrorRegistrationClosed, err, "Error mismatch")
+	assert.Nil(t, user)
+}
+
+func TestUserService_InviteUser(t *testing.T) {
+	adminID := gofakeit.Uint64()
+	ctx := utils.WithCallerID(context.Background(), adminID)
+	email := gofakeit.Email()
+	registration := domain.RegistrationPolicy{InvitationTTL: time.Hour}
+
+	cachedAdmin, _ := utils.Serialize(&domain.User{ID: adminID, Role: domain.Admin})
+	cachedAgent, _ := utils.Serialize(&domain.User{ID: adminID, Role: domain.Agent})
+
+	testCases := []struct {
+		desc  string
+		mocks func(repo *mocks.UserRepository, cache *mocks.CacheRepository)
+		err   error
+	}{
+		{
+			desc: "Success",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, utils.GenerateCacheKey("user", adminID)).Return(cachedAdmin, nil)
+				repo.On("GetUserByEmail", ctx, email).Return(nil, domain.ErrorDataNotFound)
+				repo.On("CreateInvitation", ctx, mock.MatchedBy(func(invitation *domain.Invitation) bool {
+					return invitation.Email == email &&
+						invitation.Role == domain.Agent &&
+						invitation.InvitedBy == adminID &&
+						invitation.Token == "" &&
+						len(invitation.TokenHash) == 64 &&
+						time.Until(invitation.ExpiresAt) > 59*time.Minute
+				})).Return(func(_ context.Context, invitation *domain.Invitation) (*domain.Invitation, error) {
+					invitation.ID = 1
+					return invitation, nil
+				})
+			},
+		},
+		{
+			desc: "Fail_EmailTaken",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, utils.GenerateCacheKey("user", adminID)).Return(cachedAdmin, nil)
+				repo.On("GetUserByEmail", ctx, email).Return(&domain.User{ID: 2, Email: email}, nil)
+			},
+			err: domain.ErrorConflictData,
+		},
+		{
+			desc: "Fail_NotAdmin",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				cache.On("Get", ctx, utils.GenerateCacheKey("user", adminID)).Return(cachedAgent, nil)
+			},
+			err: domain.ErrorPermissionDenied,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheRepository(t)
+			tc.mocks(repo, cache)
+			userService := service.NewUserService(repo, cache, mocks.NewUserEventRepository(t), newTransactor(t), domain.PasswordPolicy{}, registration, log.NewNopLogger())
+
+			invitation, err := userService.InviteUser(ctx, email, domain.Agent)
+
+			assert.Equal(t, tc.err, err, "Error mismatch")
+			if tc.err != nil {
+				assert.Nil(t, invitation)
+				return
+			}
+			assert.NotEmpty(t, invitation.Token, "Token not returned")
+			assert.Equal(t, utils.HashToken(invitation.Token), invitation.TokenHash, "Token hash mismatch")
+		})
+	}
+}
+
+func TestUserService_AcceptInvitation(t *testing.T) {
+	ctx := context.Background()
+	token, tokenHash, err := utils.GenerateToken()
+	assert.NoError(t, err)
+	email := gofakeit.Email()
+
+	invitation := func(expiresAt time.Time) *domain.Invitation {
+		return &domain.Invitation{ID: 1, Email: email, Role: domain.Agent, TokenHash: tokenHash, ExpiresAt: expiresAt}
+	}
+
+	newUser := mock.MatchedBy(func(user *domain.User) bool {
+		return user.Name == "Ada" &&
+			user.Email == email &&
+			user.Role == domain.Agent &&
+			utils.ComparePassword("secretpass1", user.Password) == nil
+	})
+
+	testCases := []struct {
+		desc     string
+		mocks    func(repo *mocks.UserRepository, cache *mocks.CacheRepository)
+		expected getUserExpectedOutput
+	}{
+		{
+			desc: "Success",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("ClaimInvitation", ctx, tokenHash).Return(invitation(time.Now().Add(time.Hour)), nil)
+				repo.On("CreateUser", ctx, newUser).Return(&domain.User{ID: 7, Name: "Ada", Email: email, Role: domain.Agent}, nil)
+				cache.On("Set", ctx, utils.GenerateCacheKey("user", 7), mock.Anything, mock.Anything).Return(nil)
+				cache.On("Increment", ctx, "users:generation").Return(int64(1), nil)
+			},
+			expected: getUserExpectedOutput{user: &domain.User{ID: 7, Name: "Ada", Email: email, Role: domain.Agent}},
+		},
+		{
+			desc: "Fail_Unknown",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("ClaimInvitation", ctx, tokenHash).Return(nil, domain.ErrorDataNotFound)
+			},
+			expected: getUserExpectedOutput{err: domain.ErrorInvalidInvitation},
+		},
+		{
+			desc: "Fail_Expired",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("ClaimInvitation", ctx, tokenHash).Return(invitation(time.Now().Add(-time.Minute)), nil)
+			},
+			expected: getUserExpectedOutput{err: domain.ErrorInvalidInvitation},
+		},
+		{
+			desc: "Fail_EmailTaken",
+			mocks: func(repo *mocks.UserRepository, cache *mocks.CacheRepository) {
+				repo.On("ClaimInvitation", ctx, tokenHash).Return(invitation(time.Now().Add(time.Hour)), nil)
+				repo.On("CreateUser", ctx, newUser).Return(nil, domain.ErrorConflictData)
+			},
+			expected: getUserExpectedOutput{err: domain.ErrorConflictData},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheRepository(t)
+			tc.mocks(repo, cache)
+			// Invitations are still accepted with open registration closed.
+			userService := service.NewUserService(repo, cache, mocks.NewUserEventRepository(t), newTransactor(t), domain.PasswordPolicy{}, domain.RegistrationPolicy{InviteOnly: true}, log.NewNopLogger())
+
+			user, err := userService.AcceptInvitation(ctx, token, "Ada", "secretpass1")
+
+			assert.Equal(t, tc.expected.err, err, "Error mismatch")
+			assert.Equal(t, tc.expected.user, user, "User mismatch")
+		})
+	}
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
+	"time"
 )
 
 func GenerateCacheKey(prefix string, params any) string {
@@ -31,3 +33,9 @@ func Serialize(data any) ([]byte, error) {
 func Deserialize(data []byte, output any) error {
 	return json.Unmarshal(data, output)
 }
+
+// JitterTTL adds up to a tenth of ttl so entries written together do not
+// expire together.
+func JitterTTL(ttl time.Duration) time.Duration {
+	return ttl + rand.N(ttl/10+1)
+}
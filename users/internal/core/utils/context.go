@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+)
+
+type (
+	requestIDKey struct{}
+	loggerKey    struct{}
+	callerIDKey  struct{}
+)
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func WithLogger(ctx context.Context, logger log.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+func Logger(ctx context.Context, fallback log.Logger) log.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(log.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+func WithCallerID(ctx context.Context, id uint64) context.Context {
+	return context.WithValue(ctx, callerIDKey{}, id)
+}
+
+func CallerID(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(callerIDKey{}).(uint64)
+	return id, ok
+}
@@ -0,0 +1,35 @@
+// Package api holds what the users gRPC API carries outside its protobuf
+// messages: metadata headers and error details.
+package api
+
+import "errors"
+
+const (
+	// UserIDHeader names the caller. The service trusts it only from the
+	// gateway or clients with certificates.
+	UserIDHeader           = "x-user-id"
+	RequestIDHeader        = "x-request-id"
+	IdempotencyKeyHeader   = "idempotency-key"
+	IdempotentReplayHeader = "idempotent-replayed"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details errors carry.
+const ErrorDomain = "users.radiusx"
+
+// Reasons are stable for clients to branch on.
+const (
+	ReasonEmailTaken           = "EMAIL_TAKEN"
+	ReasonUserNotFound         = "USER_NOT_FOUND"
+	ReasonNoChanges            = "NO_CHANGES"
+	ReasonInvalidCredentials   = "INVALID_CREDENTIALS"
+	ReasonPasswordReused       = "PASSWORD_REUSED"
+	ReasonRegistrationClosed   = "REGISTRATION_CLOSED"
+	ReasonInvalidInvitation    = "INVALID_INVITATION"
+	ReasonIdempotencyKeyReused = "IDEMPOTENCY_KEY_REUSED"
+	ReasonIdempotencyKeyInUse  = "IDEMPOTENCY_KEY_IN_USE"
+)
+
+var (
+	ErrorIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+	ErrorIdempotencyKeyInUse  = errors.New("request with the same idempotency key in progress")
+)
@@ -0,0 +1,332 @@
+// Package client is a Go client for the users service, speaking its domain
+// types.
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"github.com/go-kit/kit/endpoint"
+	gt "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const serviceName = "users.v1.UserService"
+
+const roundRobin = `{"loadBalancingConfig":[{"round_robin":{}}]}`
+
+type (
+	User        = domain.User
+	Role        = domain.Role
+	UserEvent   = domain.UserEvent
+	LoginEvent  = domain.LoginEvent
+	LoginOrigin = domain.LoginOrigin
+	Invitation  = domain.Invitation
+)
+
+const (
+	Reader = domain.Reader
+	Agent  = domain.Agent
+	Admin  = domain.Admin
+)
+
+type Client struct {
+	conn    *grpc.ClientConn
+	owned   bool
+	options options
+	watch   usersv1.UserServiceClient
+
+	registerEndpoint   endpoint.Endpoint
+	getUserEndpoint    endpoint.Endpoint
+	listUsersEndpoint  endpoint.Endpoint
+	updateUserEndpoint endpoint.Endpoint
+	deleteUserEndpoint endpoint.Endpoint
+
+	getMeEndpoint          endpoint.Endpoint
+	updateMeEndpoint       endpoint.Endpoint
+	changePasswordEndpoint endpoint.Endpoint
+
+	verifyCredentialsEndpoint endpoint.Endpoint
+	listLoginEventsEndpoint   endpoint.Endpoint
+
+	inviteUserEndpoint       endpoint.Endpoint
+	acceptInvitationEndpoint endpoint.Endpoint
+}
+
+// New balances calls over the addresses target resolves to.
+func New(target string, opts ...Option) (*Client, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	dialOptions := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(o.creds),
+		grpc.WithDefaultServiceConfig(roundRobin),
+	}, o.dialOptions...)
+
+	conn, err := grpc.NewClient(target, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newClient(conn, o)
+	c.owned = true
+	return c, nil
+}
+
+// NewFromConn leaves conn to the caller to close.
+func NewFromConn(conn *grpc.ClientConn, opts ...Option) *Client {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return newClient(conn, o)
+}
+
+func newClient(conn *grpc.ClientConn, o options) *Client {
+	c := &Client{conn: conn, options: o, watch: usersv1.NewUserServiceClient(conn)}
+
+	c.registerEndpoint = c.endpoint("Register", encodeRegisterRequest, decodeRegisterResponse, &usersv1.RegisterResponse{}, false)
+	c.getUserEndpoint = c.endpoint("GetUser", encodeGetUserRequest, decodeGetUserResponse, &usersv1.GetUserResponse{}, true)
+	c.listUsersEndpoint = c.endpoint("ListUsers", encodeListUsersRequest, decodeListUsersResponse, &usersv1.ListUsersResponse{}, true)
+	c.updateUserEndpoint = c.endpoint("UpdateUser", encodeUpdateUserRequest, decodeUpdateUserResponse, &usersv1.UpdateUserResponse{}, false)
+	c.deleteUserEndpoint = c.endpoint("DeleteUser", encodeDeleteUserRequest, decodeDeleteUserResponse, &usersv1.DeleteUserResponse{}, false)
+
+	c.getMeEndpoint = c.endpoint("GetMe", encodeGetMeRequest, decodeGetMeResponse, &usersv1.GetMeResponse{}, true)
+	c.updateMeEndpoint = c.endpoint("UpdateMe", encodeUpdateMeRequest, decodeUpdateMeResponse, &usersv1.UpdateMeResponse{}, false)
+	c.changePasswordEndpoint = c.endpoint("ChangePassword", encodeChangePasswordRequest, decodeChangePasswordResponse, &usersv1.ChangePasswordResponse{}, false)
+
+	c.verifyCredentialsEndpoint = c.endpoint("VerifyCredentials", encodeVerifyCredentialsRequest, decodeVerifyCredentialsResponse, &usersv1.VerifyCredentialsResponse{}, false)
+	c.listLoginEventsEndpoint = c.endpoint("ListLoginEvents", encodeListLoginEventsRequest, decodeListLoginEventsResponse, &usersv1.ListLoginEventsResponse{}, true)
+
+	c.inviteUserEndpoint = c.endpoint("InviteUser", encodeInviteUserRequest, decodeInviteUserResponse, &usersv1.InviteUserResponse{}, false)
+	c.acceptInvitationEndpoint = c.endpoint("AcceptInvitation", encodeAcceptInvitationRequest, decodeAcceptInvitationResponse, &usersv1.AcceptInvitationResponse{}, false)
+
+	return c
+}
+
+func (c *Client) endpoint(method string, enc gt.EncodeRequestFunc, dec gt.DecodeResponseFunc, reply interface{}, idempotent bool) endpoint.Endpoint {
+	ep := gt.NewClient(c.conn, serviceName, method, enc, dec, reply,
+		gt.ClientBefore(keepOutgoingMetadata, injectToken),
+	).Endpoint()
+
+	ep = decodingErrors(ep)
+	if idempotent && c.options.retry.MaxAttempts > 1 {
+		ep = retrying(c.options.retry)(ep)
+	}
+
+	return endpoint.Chain(c.deadline, c.token)(ep)
+}
+
+func (c *Client) Close() error {
+	if !c.owned {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+func (c *Client) Register(ctx context.Context, user *User) (*User, error) {
+	response, err := c.registerEndpoint(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return response.(*User), nil
+}
+
+func (c *Client) GetUser(ctx context.Context, id uint64) (*User, error) {
+	response, err := c.getUserEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return response.(*User), nil
+}
+
+func (c *Client) ListUsers(ctx context.Context, skip, limit uint64) ([]User, error) {
+	response, err := c.listUsersEndpoint(ctx, listUsersRequest{skip: skip, limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return response.([]User), nil
+}
+
+// UpdateUser leaves the empty fields of user alone.
+func (c *Client) UpdateUser(ctx context.Context, user *User) (*User, error) {
+	response, err := c.updateUserEndpoint(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return response.(*User), nil
+}
+
+func (c *Client) DeleteUser(ctx context.Context, id uint64) error {
+	_, err := c.deleteUserEndpoint(ctx, id)
+	return err
+}
+
+// AsUser makes calls on behalf of user id. Only the gateway, having
+// authenticated that user, should use it.
+func AsUser(ctx context.Context, id uint64) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, api.UserIDHeader, strconv.FormatUint(id, 10))
+}
+
+// WithIdempotencyKey makes Register, UpdateUser and DeleteUser safe to send
+// again with the same key and arguments.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, api.IdempotencyKeyHeader, key)
+}
+
+func (c *Client) GetMe(ctx context.Context) (*User, error) {
+	response, err := c.getMeEndpoint(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.(*User), nil
+}
+
+func (c *Client) UpdateMe(ctx context.Context, user *User) (*User, error) {
+	response, err := c.updateMeEndpoint(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return response.(*User), nil
+}
+
+func (c *Client) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	_, err := c.changePasswordEndpoint(ctx, changePasswordRequest{current: currentPassword, next: newPassword})
+	return err
+}
+
+// VerifyCredentials is not retried, as every call is a login attempt.
+func (c *Client) VerifyCredentials(ctx context.Context, email, password string, origin LoginOrigin) (*User, error) {
+	response, err := c.verifyCredentialsEndpoint(ctx, verifyCredentialsRequest{email: email, password: password, origin: origin})
+	if err != nil {
+		return nil, err
+	}
+	return response.(*User), nil
+}
+
+func (c *Client) ListLoginEvents(ctx context.Context, id uint64, skip, limit uint64) ([]LoginEvent, error) {
+	response, err := c.listLoginEventsEndpoint(ctx, listLoginEventsRequest{id: id, skip: skip, limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return response.([]LoginEvent), nil
+}
+
+// InviteUser returns the only copy of the invitation Token.
+func (c *Client) InviteUser(ctx context.Context, email string, role Role) (*Invitation, error) {
+	response, err := c.inviteUserEndpoint(ctx, inviteUserRequest{email: email, role: role})
+	if err != nil {
+		return nil, err
+	}
+	return response.(*Invitation), nil
+}
+
+func (c *Client) AcceptInvitation(ctx context.Context, token, name, password string) (*User, error) {
+	response, err := c.acceptInvitationEndpoint(ctx, acceptInvitationRequest{token: token, name: name, password: password})
+	if err != nil {
+		return nil, err
+	}
+	return response.(*User), nil
+}
+
+// WatchUsers is not retried; callers resume from the last sequence seen.
+func (c *Client) WatchUsers(ctx context.Context, since uint64, send func(*UserEvent) error) error {
+	ctx, err := c.withToken(ctx)
+	if err != nil {
+		return err
+	}
+	if token, ok := ctx.Value(tokenKey{}).(string); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+
+	stream, err := c.watch.WatchUsers(ctx, &usersv1.WatchUsersRequest{AfterSequence: since})
+	if err != nil {
+		return decodeError(err)
+	}
+
+	for {
+		response, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return decodeError(err)
+		}
+
+		if err := send(eventFromProto(response)); err != nil {
+			return err
+		}
+	}
+}
+
+type tokenKey struct{}
+
+func (c *Client) withToken(ctx context.Context) (context.Context, error) {
+	if c.options.token == nil {
+		return ctx, nil
+	}
+
+	token, err := c.options.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, tokenKey{}, token), nil
+}
+
+func (c *Client) token(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		ctx, err := c.withToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, request)
+	}
+}
+
+func (c *Client) deadline(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok && c.options.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.options.timeout)
+			defer cancel()
+		}
+		return next(ctx, request)
+	}
+}
+
+func decodingErrors(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := next(ctx, request)
+		if err != nil {
+			return nil, decodeError(err)
+		}
+		return response, nil
+	}
+}
+
+// keepOutgoingMetadata stops go-kit from replacing the metadata in ctx.
+func keepOutgoingMetadata(ctx context.Context, md *metadata.MD) context.Context {
+	if outgoing, ok := metadata.FromOutgoingContext(ctx); ok {
+		for key, values := range outgoing {
+			(*md)[key] = append((*md)[key], values...)
+		}
+	}
+	return ctx
+}
+
+// injectToken only forwards the token; see WithToken.
+func injectToken(ctx context.Context, md *metadata.MD) context.Context {
+	if token, ok := ctx.Value(tokenKey{}).(string); ok {
+		md.Set("authorization", "Bearer "+token)
+	}
+	return ctx
+}
@@ -0,0 +1,371 @@
+package client_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/endpoint"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/memory"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/storage/memory/repository"
+	"github.com/OzkrOssa/radiusx-users/internal/adapter/transport"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/internal/core/port"
+	"github.com/OzkrOssa/radiusx-users/internal/core/service"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"github.com/OzkrOssa/radiusx-users/pkg/client"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var _ port.UserService = (*client.Client)(nil)
+
+// serve serves srv over an in-memory listener and returns a client for it.
+func serve(t *testing.T, srv usersv1.UserServiceServer, serverOpts []grpc.ServerOption, opts ...client.Option) *client.Client {
+	server := grpc.NewServer(serverOpts...)
+	usersv1.RegisterUserServiceServer(server, srv)
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	opts = append([]client.Option{
+		client.WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		})),
+		client.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+
+	c, err := client.New("passthrough:///bufconn", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+// serveUsers serves the real service over an in-memory repository.
+func serveUsers(t *testing.T, serverOpts []grpc.ServerOption, opts ...client.Option) *client.Client {
+	repo := repository.NewUserRepository()
+	cache := memory.New(1000)
+	userService := service.NewUserService(repo, cache, nil, repo, domain.PasswordPolicy{}, domain.RegistrationPolicy{InvitationTTL: time.Hour}, log.NewNopLogger())
+
+	validator, err := transport.NewValidator()
+	require.NoError(t, err)
+
+	serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(
+		transport.IdentityUnaryInterceptor(),
+		transport.ValidationUnaryInterceptor(validator),
+		transport.IdempotencyUnaryInterceptor(cache, time.Hour),
+	))
+	return serve(t, transport.MakeGrpcTransport(*endpoint.MakeServerEndpoints(userService)), serverOpts, opts...)
+}
+
+func TestClient_Lifecycle(t *testing.T) {
+	c := serveUsers(t, nil)
+	ctx := context.Background()
+
+	created, err := c.Register(ctx, &client.User{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), created.ID)
+	assert.Equal(t, client.Reader, created.Role)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	updated, err := c.UpdateUser(ctx, &client.User{ID: created.ID, Role: client.Admin})
+	require.NoError(t, err)
+	assert.Equal(t, client.Admin, updated.Role)
+	assert.Equal(t, "Ada", updated.Name, "Unset field changed")
+
+	got, err := c.GetUser(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated.Email, got.Email)
+
+	users, err := c.ListUsers(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	require.NoError(t, c.DeleteUser(ctx, created.ID))
+}
+
+func TestClient_SelfService(t *testing.T) {
+	c := serveUsers(t, nil)
+
+	created, err := c.Register(context.Background(), &client.User{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"})
+	require.NoError(t, err)
+	ctx := client.AsUser(context.Background(), created.ID)
+
+	me, err := c.GetMe(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, me.ID)
+
+	me, err = c.UpdateMe(ctx, &client.User{Name: "Ada Lovelace"})
+	require.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", me.Name)
+	assert.Equal(t, "ada@example.com", me.Email, "Unset field changed")
+
+	err = c.ChangePassword(ctx, "wrongpass1", "newpassword1")
+	assert.ErrorIs(t, err, client.ErrorInvalidCredentials)
+
+	require.NoError(t, c.ChangePassword(ctx, "secretpass1", "newpassword1"))
+
+	_, err = c.GetMe(context.Background())
+	assert.ErrorIs(t, err, client.ErrorUnauthenticated)
+}
+
+func TestClient_Logins(t *testing.T) {
+	c := serveUsers(t, nil)
+	ctx := context.Background()
+	origin := client.LoginOrigin{IPAddress: "203.0.113.7", UserAgent: "radiusx-web/1.0"}
+
+	admin, err := c.Register(ctx, &client.User{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"})
+	require.NoError(t, err)
+	_, err = c.UpdateUser(ctx, &client.User{ID: admin.ID, Role: client.Admin})
+	require.NoError(t, err)
+	agent, err := c.Register(ctx, &client.User{Name: "Grace", Email: "grace@example.com", Password: "secretpass2"})
+	require.NoError(t, err)
+
+	_, err = c.VerifyCredentials(ctx, "grace@example.com", "wrongpass1", origin)
+	assert.ErrorIs(t, err, client.ErrorInvalidCredentials)
+
+	user, err := c.VerifyCredentials(ctx, "grace@example.com", "secretpass2", origin)
+	require.NoError(t, err)
+	assert.Equal(t, agent.ID, user.ID)
+	assert.False(t, user.LastLoginAt.IsZero(), "Last login time not set")
+
+	got, err := c.GetUser(ctx, agent.ID)
+	require.NoError(t, err)
+	assert.True(t, user.LastLoginAt.Equal(got.LastLoginAt), "Cached user kept the previous login")
+
+	events, err := c.ListLoginEvents(client.AsUser(ctx, admin.ID), agent.ID, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.True(t, events[0].Success)
+	assert.Equal(t, origin, events[0].LoginOrigin)
+	assert.Equal(t, domain.LoginFailureWrongPassword, events[1].Reason)
+
+	_, err = c.ListLoginEvents(client.AsUser(ctx, agent.ID), 0, 1, 10)
+	assert.ErrorIs(t, err, client.ErrorPermissionDenied)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestClient_Invitations(t *testing.T) {
+	c := serveUsers(t, nil)
+	ctx := context.Background()
+
+	admin, err := c.Register(ctx, &client.User{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"})
+	require.NoError(t, err)
+	_, err = c.UpdateUser(ctx, &client.User{ID: admin.ID, Role: client.Admin})
+	require.NoError(t, err)
+
+	invitation, err := c.InviteUser(client.AsUser(ctx, admin.ID), "grace@example.com", client.Agent)
+	require.NoError(t, err)
+	assert.NotEmpty(t, invitation.Token, "Token not returned")
+	assert.Equal(t, admin.ID, invitation.InvitedBy)
+	assert.Equal(t, client.Agent, invitation.Role)
+
+	user, err := c.AcceptInvitation(ctx, invitation.Token, "Grace", "secretpass2")
+	require.NoError(t, err)
+	assert.Equal(t, "grace@example.com", user.Email)
+	assert.Equal(t, client.Agent, user.Role, "Invited role not applied")
+
+	_, err = c.AcceptInvitation(ctx, invitation.Token, "Grace", "secretpass2")
+	assert.ErrorIs(t, err, client.ErrorInvalidInvitation, "Invitation accepted twice")
+
+	_, err = c.InviteUser(client.AsUser(ctx, user.ID), "eve@example.com", client.Admin)
+	assert.ErrorIs(t, err, client.ErrorPermissionDenied)
+}
+
+func TestClient_IdempotencyKey(t *testing.T) {
+	c := serveUsers(t, nil)
+	ctx := client.WithIdempotencyKey(context.Background(), "register-ada")
+
+	user, err := c.Register(ctx, &client.User{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"})
+	require.NoError(t, err)
+
+	replayed, err := c.Register(ctx, &client.User{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"})
+	require.NoError(t, err, "Retry applied twice")
+	assert.Equal(t, user.ID, replayed.ID)
+
+	_, err = c.Register(ctx, &client.User{Name: "Grace", Email: "grace@example.com", Password: "secretpass2"})
+	assert.ErrorIs(t, err, client.ErrorIdempotencyKeyReused)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	users, err := c.ListUsers(context.Background(), 1, 10)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func TestClient_Errors(t *testing.T) {
+	c := serveUsers(t, nil)
+	ctx := context.Background()
+
+	_, err := c.Register(ctx, &client.User{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		call     func() error
+		wantErr  error
+		wantCode codes.Code
+	}{
+		{
+			name: "Error_EmailTaken",
+			call: func() error {
+				_, err := c.Register(ctx, &client.User{Name: "Ada", Email: "ada@example.com", Password: "secretpass1"})
+				return err
+			},
+			wantErr:  client.ErrorConflictData,
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name: "Error_NotFound",
+			call: func() error {
+				_, err := c.GetUser(ctx, 99)
+				return err
+			},
+			wantErr:  client.ErrorDataNotFound,
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "Error_NoChanges",
+			call: func() error {
+				_, err := c.UpdateUser(ctx, &client.User{ID: 1, Name: "Ada"})
+				return err
+			},
+			wantErr:  client.ErrorNoUpdatedData,
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name: "Error_InvalidArgument",
+			call: func() error {
+				_, err := c.ListUsers(ctx, 0, 10)
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantCode, status.Code(err), "Status lost")
+		})
+	}
+}
+
+// flakyServer fails the first failures calls with UNAVAILABLE.
+type flakyServer struct {
+	usersv1.UnimplementedUserServiceServer
+	failures int32
+	calls    atomic.Int32
+}
+
+func (s *flakyServer) GetUser(_ context.Context, req *usersv1.GetUserRequest) (*usersv1.GetUserResponse, error) {
+	if s.calls.Add(1) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	return &usersv1.GetUserResponse{User: &usersv1.User{Id: req.Id}}, nil
+}
+
+func (s *flakyServer) Register(context.Context, *usersv1.RegisterRequest) (*usersv1.RegisterResponse, error) {
+	s.calls.Add(1)
+	return nil, status.Error(codes.Unavailable, "try again")
+}
+
+func TestClient_Retry(t *testing.T) {
+	policy := client.RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		failures  int32
+		call      func(c *client.Client) error
+		wantCode  codes.Code
+		wantCalls int32
+	}{
+		{
+			name:     "Success_AfterRetries",
+			failures: 2,
+			call: func(c *client.Client) error {
+				_, err := c.GetUser(context.Background(), 1)
+				return err
+			},
+			wantCode:  codes.OK,
+			wantCalls: 3,
+		},
+		{
+			name:     "Error_AttemptsExhausted",
+			failures: 5,
+			call: func(c *client.Client) error {
+				_, err := c.GetUser(context.Background(), 1)
+				return err
+			},
+			wantCode:  codes.Unavailable,
+			wantCalls: 3,
+		},
+		{
+			name: "Error_NotIdempotent",
+			call: func(c *client.Client) error {
+				_, err := c.Register(context.Background(), &client.User{})
+				return err
+			},
+			wantCode:  codes.Unavailable,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &flakyServer{failures: tt.failures}
+			c := serve(t, srv, nil, client.WithRetry(policy))
+
+			err := tt.call(c)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantCalls, srv.calls.Load())
+		})
+	}
+}
+
+func TestClient_Metadata(t *testing.T) {
+	var md metadata.MD
+	capture := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ = metadata.FromIncomingContext(ctx)
+		return handler(ctx, req)
+	}
+
+	c := serveUsers(t, []grpc.ServerOption{grpc.ChainUnaryInterceptor(capture)}, client.WithStaticToken("s3cret"))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), api.RequestIDHeader, "req-1")
+	_, _ = c.GetUser(ctx, 1)
+
+	assert.Equal(t, []string{"Bearer s3cret"}, md.Get("authorization"))
+	assert.Equal(t, []string{"req-1"}, md.Get(api.RequestIDHeader), "Outgoing metadata dropped")
+}
+
+// slowServer answers once the call is cancelled.
+type slowServer struct {
+	usersv1.UnimplementedUserServiceServer
+}
+
+func (slowServer) GetUser(ctx context.Context, _ *usersv1.GetUserRequest) (*usersv1.GetUserResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestClient_Timeout(t *testing.T) {
+	c := serve(t, slowServer{}, nil, client.WithTimeout(20*time.Millisecond))
+
+	_, err := c.GetUser(context.Background(), 1)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
@@ -0,0 +1,249 @@
+package client
+
+import (
+	"context"
+
+	usersv1 "github.com/OzkrOssa/radiusx-users/gen/users/v1"
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+)
+
+func userFromProto(pb *usersv1.User) *domain.User {
+	if pb == nil {
+		return nil
+	}
+
+	user := &domain.User{
+		ID:              pb.GetId(),
+		Name:            pb.GetName(),
+		Email:           pb.GetEmail(),
+		Role:            domain.Role(pb.GetRole().String()),
+		PasswordExpired: pb.GetPasswordExpired(),
+	}
+	if pb.CreatedAt != nil {
+		user.CreatedAt = pb.CreatedAt.AsTime()
+	}
+	if pb.UpdatedAt != nil {
+		user.UpdatedAt = pb.UpdatedAt.AsTime()
+	}
+	if pb.PasswordChangedAt != nil {
+		user.PasswordChangedAt = pb.PasswordChangedAt.AsTime()
+	}
+	if pb.LastLoginAt != nil {
+		user.LastLoginAt = pb.LastLoginAt.AsTime()
+	}
+
+	return user
+}
+
+func eventFromProto(pb *usersv1.WatchUsersResponse) *domain.UserEvent {
+	event := &domain.UserEvent{
+		Sequence:   pb.GetSequence(),
+		Type:       domain.UserEventType(pb.GetType().String()),
+		OccurredAt: pb.GetOccurredAt().AsTime(),
+	}
+	if user := userFromProto(pb.GetUser()); user != nil {
+		event.User = *user
+	}
+
+	return event
+}
+
+func encodeRegisterRequest(_ context.Context, request interface{}) (interface{}, error) {
+	user := request.(*domain.User)
+	return &usersv1.RegisterRequest{Name: user.Name, Email: user.Email, Password: user.Password}, nil
+}
+
+func decodeRegisterResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return userFromProto(response.(*usersv1.RegisterResponse).GetUser()), nil
+}
+
+func encodeGetUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &usersv1.GetUserRequest{Id: request.(uint64)}, nil
+}
+
+func decodeGetUserResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return userFromProto(response.(*usersv1.GetUserResponse).GetUser()), nil
+}
+
+type listUsersRequest struct {
+	skip, limit uint64
+}
+
+func encodeListUsersRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(listUsersRequest)
+	return &usersv1.ListUsersRequest{Skip: req.skip, Limit: req.limit}, nil
+}
+
+func decodeListUsersResponse(_ context.Context, response interface{}) (interface{}, error) {
+	pbUsers := response.(*usersv1.ListUsersResponse).GetUser()
+
+	users := make([]domain.User, 0, len(pbUsers))
+	for _, pb := range pbUsers {
+		users = append(users, *userFromProto(pb))
+	}
+
+	return users, nil
+}
+
+func encodeUpdateUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	user := request.(*domain.User)
+
+	req := &usersv1.UpdateUserRequest{Id: user.ID}
+	if user.Name != "" {
+		req.Name = &user.Name
+	}
+	if user.Email != "" {
+		req.Email = &user.Email
+	}
+	if user.Password != "" {
+		req.Password = &user.Password
+	}
+	if user.Role != "" {
+		role := usersv1.Role(usersv1.Role_value[string(user.Role)])
+		req.Role = &role
+	}
+
+	return req, nil
+}
+
+func decodeUpdateUserResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return userFromProto(response.(*usersv1.UpdateUserResponse).GetUser()), nil
+}
+
+func encodeDeleteUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &usersv1.DeleteUserRequest{Id: request.(uint64)}, nil
+}
+
+func decodeDeleteUserResponse(_ context.Context, _ interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func encodeGetMeRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return &usersv1.GetMeRequest{}, nil
+}
+
+func decodeGetMeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return userFromProto(response.(*usersv1.GetMeResponse).GetUser()), nil
+}
+
+func encodeUpdateMeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	user := request.(*domain.User)
+
+	req := &usersv1.UpdateMeRequest{}
+	if user.Name != "" {
+		req.Name = &user.Name
+	}
+	if user.Email != "" {
+		req.Email = &user.Email
+	}
+
+	return req, nil
+}
+
+func decodeUpdateMeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return userFromProto(response.(*usersv1.UpdateMeResponse).GetUser()), nil
+}
+
+type changePasswordRequest struct {
+	current, next string
+}
+
+func encodeChangePasswordRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(changePasswordRequest)
+	return &usersv1.ChangePasswordRequest{CurrentPassword: req.current, NewPassword: req.next}, nil
+}
+
+func decodeChangePasswordResponse(_ context.Context, _ interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+type verifyCredentialsRequest struct {
+	email, password string
+	origin          domain.LoginOrigin
+}
+
+func encodeVerifyCredentialsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(verifyCredentialsRequest)
+	return &usersv1.VerifyCredentialsRequest{
+		Email:     req.email,
+		Password:  req.password,
+		IpAddress: req.origin.IPAddress,
+		UserAgent: req.origin.UserAgent,
+	}, nil
+}
+
+func decodeVerifyCredentialsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return userFromProto(response.(*usersv1.VerifyCredentialsResponse).GetUser()), nil
+}
+
+type listLoginEventsRequest struct {
+	id, skip, limit uint64
+}
+
+func encodeListLoginEventsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(listLoginEventsRequest)
+	return &usersv1.ListLoginEventsRequest{UserId: req.id, Skip: req.skip, Limit: req.limit}, nil
+}
+
+func decodeListLoginEventsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	pbEvents := response.(*usersv1.ListLoginEventsResponse).GetLoginEvents()
+
+	events := make([]domain.LoginEvent, 0, len(pbEvents))
+	for _, pb := range pbEvents {
+		event := domain.LoginEvent{
+			ID:      pb.GetId(),
+			UserID:  pb.GetUserId(),
+			Email:   pb.GetEmail(),
+			Success: pb.GetSuccess(),
+			LoginOrigin: domain.LoginOrigin{
+				IPAddress: pb.GetIpAddress(),
+				UserAgent: pb.GetUserAgent(),
+			},
+			CreatedAt: pb.GetCreatedAt().AsTime(),
+		}
+		if pb.GetReason() != usersv1.LoginFailure_LOGIN_FAILURE_UNSPECIFIED {
+			event.Reason = domain.LoginFailure(pb.GetReason().String())
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+type inviteUserRequest struct {
+	email string
+	role  domain.Role
+}
+
+func encodeInviteUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(inviteUserRequest)
+	return &usersv1.InviteUserRequest{Email: req.email, Role: usersv1.Role(usersv1.Role_value[string(req.role)])}, nil
+}
+
+func decodeInviteUserResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(*usersv1.InviteUserResponse)
+	pb := resp.GetInvitation()
+
+	return &domain.Invitation{
+		ID:        pb.GetId(),
+		Email:     pb.GetEmail(),
+		Role:      domain.Role(pb.GetRole().String()),
+		Token:     resp.GetToken(),
+		InvitedBy: pb.GetInvitedBy(),
+		ExpiresAt: pb.GetExpiresAt().AsTime(),
+		CreatedAt: pb.GetCreatedAt().AsTime(),
+	}, nil
+}
+
+type acceptInvitationRequest struct {
+	token, name, password string
+}
+
+func encodeAcceptInvitationRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(acceptInvitationRequest)
+	return &usersv1.AcceptInvitationRequest{Token: req.token, Name: req.name, Password: req.password}, nil
+}
+
+func decodeAcceptInvitationResponse(_ context.Context, response interface{}) (interface{}, error) {
+	return userFromProto(response.(*usersv1.AcceptInvitationResponse).GetUser()), nil
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/OzkrOssa/radiusx-users/internal/core/domain"
+	"github.com/OzkrOssa/radiusx-users/pkg/api"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Most errors are the service domain errors.
+var (
+	ErrorDataNotFound       = domain.ErrorDataNotFound
+	ErrorConflictData       = domain.ErrorConflictData
+	ErrorNoUpdatedData      = domain.ErrorNoUpdatedData
+	ErrorInternal           = domain.ErrorInternal
+	ErrorUnauthenticated    = domain.ErrorUnauthenticated
+	ErrorInvalidCredentials = domain.ErrorInvalidCredentials
+	ErrorPasswordReused     = domain.ErrorPasswordReused
+	ErrorPermissionDenied   = domain.ErrorPermissionDenied
+	ErrorRegistrationClosed = domain.ErrorRegistrationClosed
+	ErrorInvalidInvitation  = domain.ErrorInvalidInvitation
+
+	ErrorIdempotencyKeyReused = api.ErrorIdempotencyKeyReused
+	ErrorIdempotencyKeyInUse  = api.ErrorIdempotencyKeyInUse
+)
+
+var reasons = map[string]error{
+	api.ReasonEmailTaken:           domain.ErrorConflictData,
+	api.ReasonUserNotFound:         domain.ErrorDataNotFound,
+	api.ReasonNoChanges:            domain.ErrorNoUpdatedData,
+	api.ReasonInvalidCredentials:   domain.ErrorInvalidCredentials,
+	api.ReasonPasswordReused:       domain.ErrorPasswordReused,
+	api.ReasonRegistrationClosed:   domain.ErrorRegistrationClosed,
+	api.ReasonInvalidInvitation:    domain.ErrorInvalidInvitation,
+	api.ReasonIdempotencyKeyReused: api.ErrorIdempotencyKeyReused,
+	api.ReasonIdempotencyKeyInUse:  api.ErrorIdempotencyKeyInUse,
+}
+
+// codeErrors apply when no reason is given.
+var codeErrors = map[codes.Code]error{
+	codes.NotFound:         domain.ErrorDataNotFound,
+	codes.AlreadyExists:    domain.ErrorConflictData,
+	codes.Internal:         domain.ErrorInternal,
+	codes.Unauthenticated:  domain.ErrorUnauthenticated,
+	codes.PermissionDenied: domain.ErrorPermissionDenied,
+}
+
+// statusError also matches a domain error.
+type statusError struct {
+	status *status.Status
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.status.Err().Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func (e *statusError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// decodeError returns errors without a domain error as they are.
+func decodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != api.ErrorDomain {
+			continue
+		}
+		if domainErr, ok := reasons[info.Reason]; ok {
+			return &statusError{status: st, err: domainErr}
+		}
+	}
+
+	if domainErr, ok := codeErrors[st.Code()]; ok {
+		return &statusError{status: st, err: domainErr}
+	}
+
+	return err
+}
+
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
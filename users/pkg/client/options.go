@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type TokenSource func(ctx context.Context) (string, error)
+
+type options struct {
+	creds       credentials.TransportCredentials
+	dialOptions []grpc.DialOption
+	token       TokenSource
+	timeout     time.Duration
+	retry       RetryPolicy
+}
+
+// RetryPolicy only retries the calls that read.
+type RetryPolicy struct {
+	// MaxAttempts counts the first call; 1 disables retries.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseBackoff: 100 * time.Millisecond,
+	MaxBackoff:  2 * time.Second,
+}
+
+func defaultOptions() options {
+	return options{
+		creds:   insecure.NewCredentials(),
+		timeout: 10 * time.Second,
+		retry:   DefaultRetryPolicy,
+	}
+}
+
+type Option func(*options)
+
+// WithTransportCredentials only applies to clients dialled by New.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(o *options) { o.creds = creds }
+}
+
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOptions = append(o.dialOptions, dialOptions...) }
+}
+
+// WithToken sends a bearer token for proxies in front of the service to
+// check. The users service does not verify it; it trusts the caller set by
+// AsUser only from the gateway or clients with certificates.
+func WithToken(source TokenSource) Option {
+	return func(o *options) { o.token = source }
+}
+
+func WithStaticToken(token string) Option {
+	return WithToken(func(context.Context) (string, error) { return token, nil })
+}
+
+// WithTimeout covers every attempt of calls without a deadline, except
+// WatchUsers.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *options) { o.retry = policy }
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+func retrying(policy RetryPolicy) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			for attempt := 1; ; attempt++ {
+				response, err = next(ctx, request)
+				if err == nil || !retryable(err) || attempt >= policy.MaxAttempts {
+					return response, err
+				}
+
+				timer := time.NewTimer(policy.backoff(attempt))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, err
+				case <-timer.C:
+				}
+			}
+		}
+	}
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.BaseBackoff << (attempt - 1)
+	if wait <= 0 || (p.MaxBackoff > 0 && wait > p.MaxBackoff) {
+		wait = p.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+
+	return wait - rand.N(wait/2+1)
+}